@@ -0,0 +1,401 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: club.proto
+
+package clubpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Club_Arrive_FullMethodName     = "/club.v1.Club/Arrive"
+	Club_SitDown_FullMethodName    = "/club.v1.Club/SitDown"
+	Club_Wait_FullMethodName       = "/club.v1.Club/Wait"
+	Club_Leave_FullMethodName      = "/club.v1.Club/Leave"
+	Club_Close_FullMethodName      = "/club.v1.Club/Close"
+	Club_TablesInfo_FullMethodName = "/club.v1.Club/TablesInfo"
+	Club_State_FullMethodName      = "/club.v1.Club/State"
+	Club_Events_FullMethodName     = "/club.v1.Club/Events"
+)
+
+// ClubClient is the client API for Club service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Club serves the events of a computer club. Times are "HH:MM" or
+// "HH:MM:SS" and have to go in order, as in the input file.
+type ClubClient interface {
+	Arrive(ctx context.Context, in *ClientRequest, opts ...grpc.CallOption) (*EventReply, error)
+	SitDown(ctx context.Context, in *SitDownRequest, opts ...grpc.CallOption) (*EventReply, error)
+	Wait(ctx context.Context, in *ClientRequest, opts ...grpc.CallOption) (*EventReply, error)
+	Leave(ctx context.Context, in *ClientRequest, opts ...grpc.CallOption) (*EventReply, error)
+	// Close sends the clients left in the club away at the close time.
+	Close(ctx context.Context, in *CloseRequest, opts ...grpc.CallOption) (*CloseReply, error)
+	TablesInfo(ctx context.Context, in *TablesInfoRequest, opts ...grpc.CallOption) (*TablesInfoReply, error)
+	State(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateReply, error)
+	// Events streams the events the club generates from now on.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type clubClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewClubClient(cc grpc.ClientConnInterface) ClubClient {
+	return &clubClient{cc}
+}
+
+func (c *clubClient) Arrive(ctx context.Context, in *ClientRequest, opts ...grpc.CallOption) (*EventReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventReply)
+	err := c.cc.Invoke(ctx, Club_Arrive_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) SitDown(ctx context.Context, in *SitDownRequest, opts ...grpc.CallOption) (*EventReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventReply)
+	err := c.cc.Invoke(ctx, Club_SitDown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) Wait(ctx context.Context, in *ClientRequest, opts ...grpc.CallOption) (*EventReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventReply)
+	err := c.cc.Invoke(ctx, Club_Wait_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) Leave(ctx context.Context, in *ClientRequest, opts ...grpc.CallOption) (*EventReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EventReply)
+	err := c.cc.Invoke(ctx, Club_Leave_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) Close(ctx context.Context, in *CloseRequest, opts ...grpc.CallOption) (*CloseReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseReply)
+	err := c.cc.Invoke(ctx, Club_Close_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) TablesInfo(ctx context.Context, in *TablesInfoRequest, opts ...grpc.CallOption) (*TablesInfoReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TablesInfoReply)
+	err := c.cc.Invoke(ctx, Club_TablesInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) State(ctx context.Context, in *StateRequest, opts ...grpc.CallOption) (*StateReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StateReply)
+	err := c.cc.Invoke(ctx, Club_State_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clubClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Club_ServiceDesc.Streams[0], Club_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Club_EventsClient = grpc.ServerStreamingClient[Event]
+
+// ClubServer is the server API for Club service.
+// All implementations must embed UnimplementedClubServer
+// for forward compatibility.
+//
+// Club serves the events of a computer club. Times are "HH:MM" or
+// "HH:MM:SS" and have to go in order, as in the input file.
+type ClubServer interface {
+	Arrive(context.Context, *ClientRequest) (*EventReply, error)
+	SitDown(context.Context, *SitDownRequest) (*EventReply, error)
+	Wait(context.Context, *ClientRequest) (*EventReply, error)
+	Leave(context.Context, *ClientRequest) (*EventReply, error)
+	// Close sends the clients left in the club away at the close time.
+	Close(context.Context, *CloseRequest) (*CloseReply, error)
+	TablesInfo(context.Context, *TablesInfoRequest) (*TablesInfoReply, error)
+	State(context.Context, *StateRequest) (*StateReply, error)
+	// Events streams the events the club generates from now on.
+	Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedClubServer()
+}
+
+// UnimplementedClubServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedClubServer struct{}
+
+func (UnimplementedClubServer) Arrive(context.Context, *ClientRequest) (*EventReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Arrive not implemented")
+}
+func (UnimplementedClubServer) SitDown(context.Context, *SitDownRequest) (*EventReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SitDown not implemented")
+}
+func (UnimplementedClubServer) Wait(context.Context, *ClientRequest) (*EventReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Wait not implemented")
+}
+func (UnimplementedClubServer) Leave(context.Context, *ClientRequest) (*EventReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Leave not implemented")
+}
+func (UnimplementedClubServer) Close(context.Context, *CloseRequest) (*CloseReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Close not implemented")
+}
+func (UnimplementedClubServer) TablesInfo(context.Context, *TablesInfoRequest) (*TablesInfoReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TablesInfo not implemented")
+}
+func (UnimplementedClubServer) State(context.Context, *StateRequest) (*StateReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method State not implemented")
+}
+func (UnimplementedClubServer) Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedClubServer) mustEmbedUnimplementedClubServer() {}
+func (UnimplementedClubServer) testEmbeddedByValue()              {}
+
+// UnsafeClubServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ClubServer will
+// result in compilation errors.
+type UnsafeClubServer interface {
+	mustEmbedUnimplementedClubServer()
+}
+
+func RegisterClubServer(s grpc.ServiceRegistrar, srv ClubServer) {
+	// If the following call pancis, it indicates UnimplementedClubServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Club_ServiceDesc, srv)
+}
+
+func _Club_Arrive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).Arrive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_Arrive_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).Arrive(ctx, req.(*ClientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_SitDown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SitDownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).SitDown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_SitDown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).SitDown(ctx, req.(*SitDownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_Wait_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).Wait(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_Wait_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).Wait(ctx, req.(*ClientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_Leave_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClientRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).Leave(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_Leave_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).Leave(ctx, req.(*ClientRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_Close_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).Close(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_Close_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).Close(ctx, req.(*CloseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_TablesInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TablesInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).TablesInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_TablesInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).TablesInfo(ctx, req.(*TablesInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_State_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClubServer).State(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Club_State_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClubServer).State(ctx, req.(*StateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Club_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ClubServer).Events(m, &grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Club_EventsServer = grpc.ServerStreamingServer[Event]
+
+// Club_ServiceDesc is the grpc.ServiceDesc for Club service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Club_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "club.v1.Club",
+	HandlerType: (*ClubServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Arrive",
+			Handler:    _Club_Arrive_Handler,
+		},
+		{
+			MethodName: "SitDown",
+			Handler:    _Club_SitDown_Handler,
+		},
+		{
+			MethodName: "Wait",
+			Handler:    _Club_Wait_Handler,
+		},
+		{
+			MethodName: "Leave",
+			Handler:    _Club_Leave_Handler,
+		},
+		{
+			MethodName: "Close",
+			Handler:    _Club_Close_Handler,
+		},
+		{
+			MethodName: "TablesInfo",
+			Handler:    _Club_TablesInfo_Handler,
+		},
+		{
+			MethodName: "State",
+			Handler:    _Club_State_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _Club_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "club.proto",
+}
@@ -0,0 +1,4 @@
+// Package clubpb is the gRPC API of the club, generated from club.proto.
+package clubpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative club.proto
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/config"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
+)
+
+// clubFlags adds the flags of the commands that run a club without an input
+// file. The returned function builds the club header, the parser of the
+// club events and the prices of the tables once fs is parsed and exits on
+// incorrect flags. With a time zone the working time and the tariffs are
+// put on the club day going on and the parser reads the events on it.
+func clubFlags(fs *flag.FlagSet) func() (scan.Header, parse.Parser, tariff.Plan) {
+	tables := fs.Int("tables", 3, "tables count")
+	workingTime := fs.String("hours", "09:00 19:00", "club working time")
+	hourCost := fs.Float64("cost", 10, "hour cost")
+	configPath := fs.String("config", "", "club config file overriding the flags; without a time_zone the club works in UTC")
+
+	return func() (scan.Header, parse.Parser, tariff.Plan) {
+		h := scan.Header{TablesCount: *tables, HourCost: *hourCost}
+		var p parse.Parser
+		var plan tariff.Plan
+		var err error
+		h.OpenTime, h.CloseTime, err = parse.ClubWorkingTime(*workingTime)
+		// a club with a time zone from the config may close after
+		// midnight, Apply tells whether it has one
+		if err != nil && (*configPath == "" || !errors.Is(err, parse.OpenTimeIsAfterCloseTimeError)) {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		if *configPath != "" {
+			club, err := config.Load(*configPath)
+			if err == nil {
+				h, err = club.Apply(h)
+			}
+			if err != nil {
+				report(*configPath, err)
+				os.Exit(2)
+			}
+			p.Names = club.NamePolicy()
+			plan = club.TariffPlan()
+		}
+		if clock := h.Clock(); !clock.IsZero() {
+			if h.OpenTime, err = clock.At(h.OpenTime); err == nil {
+				h.CloseTime, err = clock.At(h.CloseTime)
+			}
+			if err == nil {
+				plan, err = plan.On(clock)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(2)
+			}
+			p.Clock = clock
+		}
+		return h, p, plan
+	}
+}
+
+func newClub(h scan.Header, plan tariff.Plan) *service.ComputerClub {
+	cc := service.NewComputerClub(h.TablesCount, h.HourCost, h.OpenTime, h.CloseTime, memstore.NewStore(), memqueue.NewQueue())
+	if h.QueueCapacity > 0 {
+		cc.QueueCapacity = h.QueueCapacity
+	}
+	cc.Tariffs = plan
+	return cc
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/config"
+)
+
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: main config check file")
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet("config check", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: main config check file\n\nValidates a club config file and prints the settings it makes.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args[1:])
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	club, err := config.Load(fs.Arg(0))
+	if err != nil {
+		report(fs.Arg(0), err)
+		os.Exit(1)
+	}
+
+	fromHeader := "from input header"
+	orDefault := func(set bool, value any, otherwise string) any {
+		if set {
+			return value
+		}
+		return otherwise
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "tables\t%v\n", orDefault(club.Tables != nil, deref(club.Tables), fromHeader))
+	fmt.Fprintf(w, "open_time\t%v\n", orDefault(club.OpenTime != "", club.OpenTime, fromHeader))
+	fmt.Fprintf(w, "close_time\t%v\n", orDefault(club.CloseTime != "", club.CloseTime, fromHeader))
+	fmt.Fprintf(w, "hour_cost\t%v\n", orDefault(club.HourCost != nil, deref(club.HourCost), fromHeader))
+	fmt.Fprintf(w, "queue_capacity\t%v\n", orDefault(club.QueueCapacity != nil, deref(club.QueueCapacity), "tables count"))
+	fmt.Fprintf(w, "time_zone\t%v\n", orDefault(club.TimeZone != "", club.TimeZone, "UTC"))
+	fmt.Fprintf(w, "date\t%v\n", orDefault(club.Date != "", club.Date, "today"))
+	names := club.NamePolicy()
+	fmt.Fprintf(w, "client_names\t%v\n", orDefault(names.Unicode, "unicode", "ascii"))
+	fmt.Fprintf(w, "  max_length\t%v\n", orDefault(names.MaxLength > 0, names.MaxLength, "no limit"))
+	fmt.Fprintf(w, "  fold_case\t%v\n", names.FoldCase)
+	fmt.Fprintf(w, "shifts\t%v\n", orDefault(len(club.Shifts) > 0, len(club.Shifts), "none"))
+	for _, s := range club.ShiftPlan() {
+		fmt.Fprintf(w, "  %s\t%s\n", s.Start, s.Operator)
+	}
+	plan := club.TariffPlan()
+	fmt.Fprintf(w, "table_classes\t%v\n", orDefault(len(plan.Classes) > 0, len(plan.Classes), "none"))
+	for _, c := range plan.Classes {
+		fmt.Fprintf(w, "  %s\ttables %s, %v an hour\n", c.Name, strings.Trim(fmt.Sprint(c.Tables), "[]"), c.HourCost)
+	}
+	fmt.Fprintf(w, "tariffs\t%v\n", orDefault(len(plan.Tariffs) > 0, len(plan.Tariffs), "none"))
+	for _, t := range plan.Tariffs {
+		fmt.Fprintf(w, "  %s\t%s, %v an hour\n", t.From, orDefault(t.Class != "", t.Class, "tables out of classes"), t.HourCost)
+	}
+	w.Flush()
+
+	if club.Complete() {
+		fmt.Println("ok, the input may start right with the events (-no-header)")
+	} else {
+		fmt.Println("ok, the input has to start with the header")
+	}
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
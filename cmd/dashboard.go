@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/dashboard"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+func runDashboard(args []string) {
+	fs := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	header := clubFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: main dashboard [flags]\n\nShows the tables of the club full-screen and serves the events typed in at the front desk.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	h, p, plan := header()
+	cc := newClub(h, plan)
+	board := dashboard.New(cc, func() store.DayTime {
+		now := time.Now().Truncate(time.Minute)
+		if p.Clock.IsZero() {
+			now = now.In(h.Zone())
+			return store.NewDayTime(now.Hour(), now.Minute())
+		}
+		// a moment off the club day is kept, the parser rejects the
+		// events at it
+		d, _ := p.Clock.On(now)
+		d.Seconds = false
+		return d
+	})
+	board.Parser = p
+
+	restore, err := rawMode()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dashboard needs a terminal:", err)
+		os.Exit(1)
+	}
+	defer restore()
+
+	keys := make(chan rune)
+	go func() {
+		in := bufio.NewReader(os.Stdin)
+		for {
+			r, _, err := in.ReadRune()
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- r
+		}
+	}()
+
+	terminal := dashboard.Terminal{W: os.Stdout}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		if err := board.Tick(); err != nil {
+			restore()
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		v, err := board.View()
+		if err != nil {
+			restore()
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		terminal.Draw(dashboard.Render(v, terminalWidth()))
+
+		select {
+		case r, ok := <-keys:
+			// Ctrl-C doesn't send a signal in raw mode
+			if !ok || r == 3 || board.Key(r) {
+				return
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// rawMode turns off line editing and echo of the terminal on stdin and
+// returns the function that turns them back on.
+func rawMode() (restore func(), err error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() {
+		stty(strings.TrimSpace(saved))
+		fmt.Print("\x1b[H\x1b[2J")
+	}, nil
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func terminalWidth() int {
+	size, err := stty("size")
+	if err != nil {
+		return 80
+	}
+	var rows, columns int
+	if _, err := fmt.Sscan(size, &rows, &columns); err != nil || columns == 0 {
+		return 80
+	}
+	return columns
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/generate"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+)
+
+func runGenerate(args []string) {
+	cfg := generate.DefaultConfig()
+
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	fs.IntVar(&cfg.Tables, "tables", cfg.Tables, "tables count")
+	hours := fs.String("hours", fmt.Sprint(cfg.OpenTime, " ", cfg.CloseTime), "club working time, 'XX:XX XX:XX'")
+	fs.Float64Var(&cfg.HourCost, "cost", cfg.HourCost, "hour cost")
+	fs.Float64Var(&cfg.ArrivalRate, "rate", cfg.ArrivalRate, "mean arrivals per hour")
+	dist := fs.String("session", "exp", "session length distribution: exp, uniform or fixed")
+	fs.DurationVar(&cfg.SessionMean, "session-mean", cfg.SessionMean, "mean session length")
+	fs.Float64Var(&cfg.WaitPropensity, "wait", cfg.WaitPropensity, "probability that a client waits when all tables are busy")
+	fs.Float64Var(&cfg.ErrorRate, "errors", cfg.ErrorRate, "probability of injecting a rule-violating event per arrival")
+	fs.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed")
+	fs.Parse(args)
+
+	var err error
+	if cfg.OpenTime, cfg.CloseTime, err = parse.ClubWorkingTime(*hours); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.SessionDist, err = generate.ParseSessionDistribution(*dist); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if err := generate.Generate(os.Stdout, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -1,31 +1,312 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/config"
+	"github.com/GerogeGol/yadro-test-problem/domain/feed"
+	"github.com/GerogeGol/yadro-test-problem/domain/i18n"
+	"github.com/GerogeGol/yadro-test-problem/domain/metrics"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
 	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/shift"
+	"github.com/GerogeGol/yadro-test-problem/domain/webhook"
 )
 
 func main() {
-	if len(os.Args) == 1 {
-		panic("no specified file")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "generate":
+			runGenerate(os.Args[2:])
+			return
+		case "simulate":
+			runSimulate(os.Args[2:])
+			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		case "dashboard":
+			runDashboard(os.Args[2:])
+			return
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		}
 	}
-	filepath := os.Args[1]
 
-	file, err := os.Open(filepath)
+	fs := flag.NewFlagSet("main", flag.ExitOnError)
+	onError := fs.String("on-error", "discard", "what to print on incorrect input: 'discard' prints only the offending line, 'flush' prints processed lines first")
+	follow := fs.Bool("follow", false, "keep reading lines appended to the input and print events as they happen; the club is closed on SIGINT, SIGTERM or at its close time")
+	langName := fs.String("lang", "", "explain errors for staff in this language: 'en' or 'ru'; the protocol output is kept when empty")
+	configPath := fs.String("config", "", "club config file overriding the settings from the input header; without a time_zone in either the club works in UTC and timestamps are converted to UTC")
+	noHeader := fs.Bool("no-header", false, "the input starts right with the events, all settings come from -config")
+	corrections := fs.Bool("corrections", false, "serve the void (5) and amend (6) events; the club keeps every event of the day for them")
+	inputName := fs.String("input", "auto", "input format: 'text', 'jsonl' or 'auto' to detect it from the first line")
+	poll := fs.Duration("poll", 200*time.Millisecond, "how often to check for new lines with -follow")
+	logLevel := fs.String("log-level", "", "log why the club answers events the way it does to stderr: 'debug', 'info', 'warn' or 'error'; nothing is logged when empty")
+	logFormat := fs.String("log-format", "text", "log format: 'text' or 'json'")
+	metricsAddr := fs.String("metrics", "", "serve Prometheus metrics of the club at this address, e.g. ':9090', under /metrics, while the input is processed, with -follow until the club closes")
+	eventsAddr := fs.String("events", "", "stream the events generated by the club as Server-Sent Events at this address, e.g. ':8080', under /events, while the input is processed, with -follow until the club closes")
+	webhooks := webhookURLs{}
+	fs.Var(webhooks, "webhook", "post notifications of a type to a URL as type=url, may be repeated; types: "+strings.Join(webhook.Types, ", ")+"; the payloads are signed with $CLUB_WEBHOOK_SECRET, which must be set")
+	outboxPath := fs.String("webhook-outbox", "", "keep the notifications not delivered yet in this file, so that they are sent after a restart")
+	webhookWait := fs.Duration("webhook-wait", 10*time.Second, "how long to keep delivering notifications after the input is processed")
+	shiftsPath := fs.String("shifts", "", "write the report of every shift set in -config to this file as the shift is handed over")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: main [flags] [file]\n\nReads standard input when file is '-' or omitted.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+
+	mode, err := scan.ParseErrorMode(*onError)
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
-	defer file.Close()
 
-	buf := &strings.Builder{}
-	line, err := scan.ScanInputData(file, buf)
+	format, err := scan.ParseInputFormat(*inputName)
 	if err != nil {
-		fmt.Println(line)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var lang i18n.Lang
+	localize := func(err error) error { return err }
+	if *langName != "" {
+		if lang, err = i18n.ParseLang(*langName); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		localize = func(err error) error { return i18n.Localize(lang, err) }
+	}
+
+	p := &scan.Processor{NoHeader: *noHeader, Input: format, Corrections: *corrections}
+	if *configPath != "" {
+		club, err := config.Load(*configPath)
+		if err != nil {
+			report(*configPath, localize(err))
+			os.Exit(2)
+		}
+		if _, err := club.Apply(scan.Header{}); *noHeader && err != nil {
+			report(*configPath, localize(err))
+			os.Exit(2)
+		}
+		p.Configure = club.Apply
+		p.Parser.Names = club.NamePolicy()
+		p.Shifts = club.ShiftPlan()
+		p.Tariffs = club.TariffPlan()
+	} else if *noHeader {
+		fmt.Fprintln(os.Stderr, "-no-header requires -config")
+		os.Exit(2)
+	}
+
+	if lang != "" {
+		p.Format = i18n.EventFormatter(lang)
+	}
+
+	if *shiftsPath != "" {
+		if len(p.Shifts) == 0 {
+			fmt.Fprintln(os.Stderr, "-shifts requires shifts in -config")
+			os.Exit(2)
+		}
+		file, err := os.Create(*shiftsPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer file.Close()
+		p.Handover = func(r shift.Report) {
+			fmt.Fprintf(file, "%s\n\n", r)
+		}
+	}
+
+	muxes := map[string]*http.ServeMux{}
+	handle := func(addr, path string, h http.Handler) {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		muxes[addr].Handle(path, h)
+	}
+	if *metricsAddr != "" {
+		m := metrics.New()
+		p.DomainEvents = bus.New[service.DomainEvent]()
+		p.DomainEvents.Handle(m.Handle)
+		handle(*metricsAddr, "/metrics", m)
+	}
+	if *eventsAddr != "" {
+		p.Events = bus.New[event.Event]()
+		handle(*eventsAddr, "/events", feed.Handler{Bus: p.Events})
+	}
+	var sink *webhook.Sink
+	if len(webhooks) > 0 || *outboxPath != "" {
+		var outbox webhook.Outbox = webhook.NewMemoryOutbox()
+		if *outboxPath != "" {
+			outbox = webhook.NewFileOutbox(*outboxPath)
+		}
+		var err error
+		sink, err = webhook.NewSink(webhooks, []byte(os.Getenv("CLUB_WEBHOOK_SECRET")), outbox)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "CLUB_WEBHOOK_SECRET:", err)
+			os.Exit(2)
+		}
+		if p.DomainEvents == nil {
+			p.DomainEvents = bus.New[service.DomainEvent]()
+		}
+		p.DomainEvents.Handle(sink.Handle)
+	}
+	for addr, mux := range muxes {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		go func() {
+			if err := http.Serve(listener, mux); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
+	input, name := os.Stdin, "<stdin>"
+	if path := fs.Arg(0); path != "" && path != "-" {
+		name = path
+		file, err := os.Open(path)
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	if *logLevel != "" {
+		logger, err := newLogger(*logLevel, *logFormat)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		p.Logger = logger.With("club", name)
+		if sink != nil {
+			sink.Logger = logger
+		}
+	}
+	stopWebhooks := func() {}
+	if sink != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- sink.Run(ctx) }()
+		stopWebhooks = func() {
+			cancel()
+			if err := <-done; err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			drainWebhooks(sink, *webhookWait)
+		}
+	}
+
+	if *follow {
+		err = runFollow(p, input, *poll)
+	} else {
+		err = run(p, input, mode)
+	}
+	stopWebhooks()
+
+	if err == nil {
 		return
 	}
-	fmt.Print(buf)
+	report(name, localize(err))
+	if mode == scan.FlushOnError || *follow {
+		os.Exit(1)
+	}
+}
+
+// drainWebhooks delivers the notifications left, what isn't delivered in
+// wait stays in the outbox.
+func drainWebhooks(sink *webhook.Sink, wait time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+	if err := sink.Drain(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "notifications not delivered:", err)
+	}
+}
+
+func newLogger(level string, format string) (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("-log-level: %w", err)
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	}
+	return nil, fmt.Errorf("-log-format: unknown format %q", format)
+}
 
+// report prints a compiler-style diagnostic for incorrect input to stderr.
+func report(name string, err error) {
+	var perr *parse.ParseError
+	if errors.As(err, &perr) && perr.Line > 0 {
+		fmt.Fprint(os.Stderr, perr.Diagnostic(name))
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+func run(p *scan.Processor, input io.Reader, mode scan.ErrorMode) error {
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	return p.Process(input, out, mode)
+}
+
+// runFollow processes lines as they are appended to input and prints the
+// output unbuffered. The club is closed on a signal or at its close time.
+func runFollow(p *scan.Processor, input *os.File, poll time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p.OnHeader = func(h scan.Header) {
+		// with a time zone the close time is already on the calendar,
+		// otherwise it is put on the club day going on in UTC
+		closeAt := h.CloseTime
+		if h.Location == nil {
+			h.Location = time.UTC
+			var err error
+			if closeAt, err = h.Clock().At(h.CloseTime); err != nil {
+				fmt.Fprintf(os.Stderr, "close time %s: %s, the club is closed on a signal\n", h.CloseTime, err)
+				return
+			}
+		}
+		wait := time.Until(closeAt.Time)
+		if wait <= 0 {
+			fmt.Fprintf(os.Stderr, "close time %s has passed, the club is closed on a signal or before the first later event\n", h.CloseTime)
+			return
+		}
+		time.AfterFunc(wait, cancel)
+	}
+
+	line, err := p.ScanInputData(scan.NewFollowReader(ctx, input, poll), os.Stdout)
+	if err != nil {
+		fmt.Println(line)
+	}
+	return err
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+
+	"google.golang.org/grpc"
+
+	"github.com/GerogeGol/yadro-test-problem/api/clubpb"
+	"github.com/GerogeGol/yadro-test-problem/domain/rpc"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+)
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":50051", "address to serve the gRPC API of the club at")
+	header := clubFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: main serve [flags]\n\nServes the events of the club over gRPC, see api/clubpb/club.proto.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	h, p, plan := header()
+
+	server := rpc.NewServer(service.NewService(newClub(h, plan)))
+	server.Parser = p
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	s := grpc.NewServer()
+	clubpb.RegisterClubServer(s, server)
+	if err := s.Serve(lis); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/simulate"
+)
+
+func runSimulate(args []string) {
+	var s simulate.Scenario
+
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	fs.IntVar(&s.Tables, "tables", 0, "tables count, 0 keeps the one from the log")
+	addTables := fs.Int("add-tables", 0, "tables to add to the count from the log")
+	fs.IntVar(&s.QueueCapacity, "queue", 0, "wait queue capacity, 0 keeps the tables count")
+	fs.Float64Var(&s.PriceFactor, "price-factor", 1, "hour cost multiplier")
+	fs.Float64Var(&s.Elasticity, "elasticity", 0, "price elasticity of demand")
+	fs.Int64Var(&s.Seed, "seed", 0, "random seed for elastic demand")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: main simulate [flags] file")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	log, _, err := scan.ReadLog(file)
+	if err != nil {
+		report(fs.Arg(0), err)
+		os.Exit(1)
+	}
+
+	if *addTables != 0 {
+		if s.Tables == 0 {
+			s.Tables = log.TablesCount
+		}
+		s.Tables += *addTables
+	}
+
+	comparison, err := simulate.WhatIf(log, s)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Print(comparison)
+}
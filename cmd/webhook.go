@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/webhook"
+)
+
+// webhookURLs is the repeated -webhook flag, type=url.
+type webhookURLs map[string][]string
+
+func (w webhookURLs) String() string {
+	var pairs []string
+	for typ, urls := range w {
+		for _, url := range urls {
+			pairs = append(pairs, typ+"="+url)
+		}
+	}
+	return strings.Join(pairs, " ")
+}
+
+func (w webhookURLs) Set(s string) error {
+	typ, url, ok := strings.Cut(s, "=")
+	if !ok || url == "" {
+		return fmt.Errorf("want type=url, got %q", s)
+	}
+	typ, err := webhook.ParseType(typ)
+	if err != nil {
+		return err
+	}
+	w[typ] = append(w[typ], url)
+	return nil
+}
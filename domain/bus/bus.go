@@ -0,0 +1,124 @@
+package bus
+
+import (
+	"slices"
+	"sync"
+)
+
+// Bus delivers every published value to all subscribers. Publish never
+// blocks on subscriptions: a subscriber that doesn't keep up loses its
+// oldest values, so a slow consumer can't hold up the club. Handlers are
+// the opposite, they are called by Publish and get every value.
+type Bus[T any] struct {
+	mu       sync.Mutex
+	subs     map[*Subscription[T]]struct{}
+	handlers []*handler[T]
+}
+
+type handler[T any] struct {
+	f func(T)
+}
+
+func New[T any]() *Bus[T] {
+	return &Bus[T]{subs: map[*Subscription[T]]struct{}{}}
+}
+
+// Subscription receives the values published after it was made.
+type Subscription[T any] struct {
+	bus     *Bus[T]
+	c       chan T
+	dropped uint64
+}
+
+// Subscribe returns a subscription keeping up to buffer values the
+// subscriber hasn't received yet, at least one.
+func (b *Bus[T]) Subscribe(buffer int) *Subscription[T] {
+	s := &Subscription[T]{bus: b, c: make(chan T, max(buffer, 1))}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Handle makes Publish call h with every value, in the order the handlers
+// were added. h runs in the publishing goroutine and may publish itself.
+// Call cancel to remove h.
+func (b *Bus[T]) Handle(h func(T)) (cancel func()) {
+	entry := &handler[T]{f: h}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, entry)
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.handlers = slices.DeleteFunc(b.handlers, func(h *handler[T]) bool { return h == entry })
+	}
+}
+
+func (b *Bus[T]) Publish(v T) {
+	for _, h := range b.send(v) {
+		h.f(v)
+	}
+}
+
+// send delivers v to the subscriptions and returns the handlers to call
+// once the bus is unlocked.
+func (b *Bus[T]) send(v T) []*handler[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		select {
+		case s.c <- v:
+			continue
+		default:
+		}
+		// the buffer is full, make room by dropping the oldest value; the
+		// subscriber may have taken one meanwhile, then nothing is lost
+		select {
+		case <-s.c:
+			s.dropped++
+		default:
+		}
+		s.c <- v
+	}
+	return slices.Clone(b.handlers)
+}
+
+// Subscribers returns the number of subscriptions.
+func (b *Bus[T]) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Listened reports whether a value published now would reach anyone, so
+// that a publisher can skip making values nobody gets.
+func (b *Bus[T]) Listened() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs) > 0 || len(b.handlers) > 0
+}
+
+// C is closed when the subscription is cancelled.
+func (s *Subscription[T]) C() <-chan T {
+	return s.c
+}
+
+// Dropped returns the number of values lost because the subscriber was too
+// slow to receive them.
+func (s *Subscription[T]) Dropped() uint64 {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.dropped
+}
+
+// Unsubscribe cancels the subscription and closes C. It may be called more
+// than once.
+func (s *Subscription[T]) Unsubscribe() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.c)
+	}
+}
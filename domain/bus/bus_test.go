@@ -0,0 +1,105 @@
+package bus_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("every subscriber gets every value", func(t *testing.T) {
+		b := bus.New[int]()
+		first, second := b.Subscribe(2), b.Subscribe(2)
+
+		b.Publish(1)
+		b.Publish(2)
+
+		for _, s := range []*bus.Subscription[int]{first, second} {
+			test.AssertEqual(t, <-s.C(), 1)
+			test.AssertEqual(t, <-s.C(), 2)
+		}
+	})
+
+	t.Run("unsubscribe closes the channel", func(t *testing.T) {
+		b := bus.New[int]()
+		s := b.Subscribe(1)
+		test.AssertEqual(t, b.Subscribers(), 1)
+
+		s.Unsubscribe()
+		s.Unsubscribe()
+		test.AssertEqual(t, b.Subscribers(), 0)
+
+		b.Publish(1)
+		_, ok := <-s.C()
+		test.AssertFalse(t, ok)
+	})
+
+	t.Run("slow subscriber loses the oldest values", func(t *testing.T) {
+		b := bus.New[int]()
+		slow, fast := b.Subscribe(2), b.Subscribe(10)
+
+		for i := 1; i <= 5; i++ {
+			b.Publish(i)
+		}
+
+		test.AssertEqual(t, slow.Dropped(), uint64(3))
+		test.AssertEqual(t, <-slow.C(), 4)
+		test.AssertEqual(t, <-slow.C(), 5)
+		test.AssertEqual(t, fast.Dropped(), uint64(0))
+		test.AssertEqual(t, len(fast.C()), 5)
+	})
+
+	t.Run("publish doesn't wait for subscribers", func(t *testing.T) {
+		b := bus.New[int]()
+		subs := make([]*bus.Subscription[int], 4)
+		for i := range subs {
+			subs[i] = b.Subscribe(8)
+		}
+
+		var wg sync.WaitGroup
+		for _, s := range subs {
+			wg.Add(1)
+			go func(s *bus.Subscription[int]) {
+				defer wg.Done()
+				last := 0
+				for v := range s.C() {
+					if v <= last {
+						t.Errorf("got %d after %d", v, last)
+					}
+					last = v
+				}
+			}(s)
+		}
+		for i := 1; i <= 10000; i++ {
+			b.Publish(i)
+		}
+		for _, s := range subs {
+			s.Unsubscribe()
+		}
+		wg.Wait()
+	})
+	t.Run("handlers get every value in order", func(t *testing.T) {
+		b := bus.New[int]()
+		slow := b.Subscribe(1)
+		var got []string
+		b.Handle(func(v int) { got = append(got, fmt.Sprint("first ", v)) })
+		cancel := b.Handle(func(v int) {
+			got = append(got, fmt.Sprint("second ", v))
+			if v == 1 {
+				// handlers may publish, the bus isn't locked
+				b.Publish(2)
+			}
+		})
+
+		b.Publish(1)
+		cancel()
+		b.Publish(3)
+
+		test.AssertEqual(t, strings.Join(got, ", "), "first 1, second 1, first 2, second 2, first 3")
+		test.AssertEqual(t, slow.Dropped(), uint64(2))
+	})
+}
@@ -0,0 +1,471 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/shift"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
+)
+
+var IncorrectConfig = fmt.Errorf("incorrect club config")
+var UnknownSetting = fmt.Errorf("unknown setting")
+var MissingSetting = fmt.Errorf("setting is required when the input has no header")
+var UnknownTimeZone = parse.UnknownTimeZone
+var IncorrectDate = fmt.Errorf("incorrect date format. Should be 'YYYY-MM-DD'")
+var DateWithoutTimeZone = fmt.Errorf("date is only used with time_zone")
+var IncorrectOperatorName = fmt.Errorf("operator name should be a word without spaces")
+var ShiftsOutOfOrder = fmt.Errorf("shift could not start before the previous one")
+var IncorrectClassName = fmt.Errorf("table class name should be a word without spaces")
+var DuplicateClass = fmt.Errorf("table class is already defined")
+var TableInTwoClasses = fmt.Errorf("table is already in another class")
+var TableOutOfRange = fmt.Errorf("table number is greater than the tables count")
+var UnknownClass = fmt.Errorf("table class is not defined in table_classes")
+var TariffsOutOfOrder = fmt.Errorf("tariff could not start before the previous one of the class")
+
+// Club is a club config file. Settings left out of the file are taken from
+// the input header.
+type Club struct {
+	Tables        *int     `json:"tables,omitempty"`
+	OpenTime      string   `json:"open_time,omitempty"`
+	CloseTime     string   `json:"close_time,omitempty"`
+	HourCost      *float64 `json:"hour_cost,omitempty"`
+	QueueCapacity *int     `json:"queue_capacity,omitempty"`
+	TimeZone      string   `json:"time_zone,omitempty"`
+	Date          string   `json:"date,omitempty"`
+	ClientNames   *Names   `json:"client_names,omitempty"`
+	Shifts        []Shift  `json:"shifts,omitempty"`
+	TableClasses  []Class  `json:"table_classes,omitempty"`
+	Tariffs       []Tariff `json:"tariffs,omitempty"`
+}
+
+// Class is a kind of tables with an hour cost of its own.
+type Class struct {
+	Name     string  `json:"name"`
+	Tables   []int   `json:"tables"`
+	HourCost float64 `json:"hour_cost"`
+}
+
+// Tariff is the hour cost of the sessions started from the From time on, at
+// the tables of Class, or at the tables out of any class without one.
+type Tariff struct {
+	From     string  `json:"from"`
+	Class    string  `json:"class,omitempty"`
+	HourCost float64 `json:"hour_cost"`
+}
+
+// Shift is an operator taking over the club at the start time.
+type Shift struct {
+	Start    string `json:"start"`
+	Operator string `json:"operator"`
+}
+
+// Names is the client name policy.
+type Names struct {
+	// Charset is "ascii" for the a..z names of the task or "unicode" for
+	// letters of any script.
+	Charset   string `json:"charset,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+	FoldCase  bool   `json:"fold_case,omitempty"`
+}
+
+// NamePolicy returns the client name policy, the one of the task if the
+// config doesn't set it.
+func (c Club) NamePolicy() parse.NamePolicy {
+	if c.ClientNames == nil {
+		return parse.NamePolicy{}
+	}
+	return parse.NamePolicy{
+		Unicode:   c.ClientNames.Charset == "unicode",
+		MaxLength: c.ClientNames.MaxLength,
+		FoldCase:  c.ClientNames.FoldCase,
+	}
+}
+
+// Load reads and validates the config file at path.
+func Load(path string) (Club, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Club{}, fmt.Errorf("config.Load: %w", err)
+	}
+	defer file.Close()
+	return Read(file)
+}
+
+// Read decodes and validates a JSON config. Errors are *parse.ParseError
+// pointing at the offending setting in the source.
+func Read(r io.Reader) (c Club, err error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return c, fmt.Errorf("config.Read: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&c); err != nil {
+		return c, decodeError(src, err)
+	}
+	if decoder.More() {
+		return c, locateOffset(src, decoder.InputOffset(), fmt.Errorf("unexpected data after the config: %w", IncorrectConfig))
+	}
+
+	if err = c.Validate(); err != nil {
+		return c, locateSetting(src, err)
+	}
+	return c, nil
+}
+
+// Validate checks every setting present in the config on its own and the
+// working time if both ends are set. A club with a time zone may close
+// after midnight, so its close time may be before the open time.
+func (c Club) Validate() error {
+	if c.Tables != nil && *c.Tables <= 0 {
+		return settingError("tables", strconv.Itoa(*c.Tables), parse.LessOrEqualZeroError)
+	}
+	if c.HourCost != nil && *c.HourCost <= 0 {
+		return settingError("hour_cost", fmt.Sprint(*c.HourCost), parse.LessOrEqualZeroError)
+	}
+	if c.QueueCapacity != nil && *c.QueueCapacity < 0 {
+		return settingError("queue_capacity", strconv.Itoa(*c.QueueCapacity), fmt.Errorf("value could not be negative: %w", IncorrectConfig))
+	}
+
+	var h scan.Header
+	if err := c.times(&h); err != nil {
+		return err
+	}
+	if c.OpenTime != "" && c.CloseTime != "" && c.TimeZone == "" && h.OpenTime.Compare(h.CloseTime.Time) == 1 {
+		return settingError("close_time", c.CloseTime, parse.OpenTimeIsAfterCloseTimeError)
+	}
+
+	if _, err := c.location(); err != nil {
+		return err
+	}
+	if _, err := c.date(); err != nil {
+		return err
+	}
+
+	if _, err := c.shifts(); err != nil {
+		return err
+	}
+	if _, err := c.plan(); err != nil {
+		return err
+	}
+
+	if names := c.ClientNames; names != nil {
+		if names.Charset != "" && names.Charset != "ascii" && names.Charset != "unicode" {
+			return settingError("charset", names.Charset, fmt.Errorf("should be 'ascii' or 'unicode': %w", IncorrectConfig))
+		}
+		if names.MaxLength < 0 {
+			return settingError("max_length", strconv.Itoa(names.MaxLength), fmt.Errorf("value could not be negative: %w", IncorrectConfig))
+		}
+	}
+	return nil
+}
+
+// Complete reports whether the config sets everything the input header
+// does, so that the input may start right with the events.
+func (c Club) Complete() bool {
+	_, missing := c.missing()
+	return !missing
+}
+
+// Apply overrides h with the settings present in the config. With a zero h
+// the config has to be complete.
+func (c Club) Apply(h scan.Header) (scan.Header, error) {
+	if h == (scan.Header{}) {
+		if key, ok := c.missing(); ok {
+			return h, fmt.Errorf("Club.Apply: %w", settingError(key, "", MissingSetting))
+		}
+	}
+
+	if c.Tables != nil {
+		h.TablesCount = *c.Tables
+	}
+	if c.HourCost != nil {
+		h.HourCost = *c.HourCost
+	}
+	if c.QueueCapacity != nil {
+		h.QueueCapacity = *c.QueueCapacity
+	}
+	if err := c.times(&h); err != nil {
+		return h, fmt.Errorf("Club.Apply: %w", err)
+	}
+	location, err := c.location()
+	if err != nil {
+		return h, fmt.Errorf("Club.Apply: %w", err)
+	}
+	if location != nil {
+		h.Location = location
+	}
+	date, err := c.date()
+	if err != nil {
+		return h, fmt.Errorf("Club.Apply: %w", err)
+	}
+	if !date.IsZero() {
+		h.Date = date
+	}
+
+	if h.Location == nil && h.OpenTime.Compare(h.CloseTime.Time) == 1 {
+		return h, fmt.Errorf("Club.Apply: %w", settingError("open_time", h.OpenTime.String(), parse.OpenTimeIsAfterCloseTimeError))
+	}
+	// the tables count may come from the header
+	for _, class := range c.TableClasses {
+		for _, table := range class.Tables {
+			if table > h.TablesCount {
+				return h, fmt.Errorf("Club.Apply: %w", settingError("name", class.Name, fmt.Errorf("table %d: %w", table, TableOutOfRange)))
+			}
+		}
+	}
+	return h, nil
+}
+
+// TariffPlan returns the prices of the tables, the zero Plan if the config
+// sets no table classes or tariffs.
+func (c Club) TariffPlan() tariff.Plan {
+	plan, _ := c.plan()
+	return plan
+}
+
+// plan parses the table classes and the tariffs. Like the shifts, the
+// tariffs of a class are only checked to be in order without a time zone.
+func (c Club) plan() (tariff.Plan, error) {
+	var plan tariff.Plan
+	classOf := map[int]string{}
+	for _, class := range c.TableClasses {
+		if class.Name == "" || strings.ContainsFunc(class.Name, unicode.IsSpace) {
+			return plan, settingError("name", class.Name, IncorrectClassName)
+		}
+		if slices.ContainsFunc(plan.Classes, func(other tariff.Class) bool { return other.Name == class.Name }) {
+			return plan, settingError("name", class.Name, DuplicateClass)
+		}
+		// the numbers are found in the config by the class name
+		if class.HourCost <= 0 {
+			return plan, settingError("name", class.Name, fmt.Errorf("hour_cost %v: %w", class.HourCost, parse.LessOrEqualZeroError))
+		}
+		for _, table := range class.Tables {
+			var err error
+			switch {
+			case table <= 0:
+				err = parse.LessOrEqualZeroError
+			case c.Tables != nil && table > *c.Tables:
+				err = TableOutOfRange
+			case classOf[table] != "":
+				err = TableInTwoClasses
+			}
+			if err != nil {
+				return plan, settingError("name", class.Name, fmt.Errorf("table %d: %w", table, err))
+			}
+			classOf[table] = class.Name
+		}
+		plan.Classes = append(plan.Classes, tariff.Class{Name: class.Name, Tables: slices.Clone(class.Tables), HourCost: class.HourCost})
+	}
+
+	last := map[string]store.DayTime{}
+	for _, t := range c.Tariffs {
+		from, err := parse.DayTime(t.From)
+		if err != nil {
+			return plan, settingError("from", t.From, errors.Unwrap(err))
+		}
+		if t.Class != "" && !slices.ContainsFunc(plan.Classes, func(class tariff.Class) bool { return class.Name == t.Class }) {
+			return plan, settingError("class", t.Class, UnknownClass)
+		}
+		if t.HourCost <= 0 {
+			return plan, settingError("from", t.From, fmt.Errorf("hour_cost %v: %w", t.HourCost, parse.LessOrEqualZeroError))
+		}
+		if previous, ok := last[t.Class]; ok && c.TimeZone == "" && from.Compare(previous.Time) <= 0 {
+			return plan, settingError("from", t.From, TariffsOutOfOrder)
+		}
+		last[t.Class] = from
+		plan.Tariffs = append(plan.Tariffs, tariff.Tariff{From: from, Class: t.Class, HourCost: t.HourCost})
+	}
+	return plan, nil
+}
+
+// ShiftPlan returns the shifts of the club day, none if the config doesn't
+// set them.
+func (c Club) ShiftPlan() []shift.Shift {
+	shifts, _ := c.shifts()
+	return shifts
+}
+
+// shifts parses the shifts. A club with a time zone may work past midnight,
+// so the order is only checked without one.
+func (c Club) shifts() ([]shift.Shift, error) {
+	var shifts []shift.Shift
+	for i, s := range c.Shifts {
+		start, err := parse.DayTime(s.Start)
+		if err != nil {
+			return nil, settingError("start", s.Start, errors.Unwrap(err))
+		}
+		if s.Operator == "" || strings.ContainsFunc(s.Operator, unicode.IsSpace) {
+			return nil, settingError("operator", s.Operator, IncorrectOperatorName)
+		}
+		if i > 0 && c.TimeZone == "" && start.Compare(shifts[i-1].Start.Time) <= 0 {
+			return nil, settingError("start", s.Start, ShiftsOutOfOrder)
+		}
+		shifts = append(shifts, shift.Shift{Start: start, Operator: s.Operator})
+	}
+	return shifts, nil
+}
+
+// missing returns the first header setting absent from the config.
+func (c Club) missing() (string, bool) {
+	switch {
+	case c.Tables == nil:
+		return "tables", true
+	case c.OpenTime == "":
+		return "open_time", true
+	case c.CloseTime == "":
+		return "close_time", true
+	case c.HourCost == nil:
+		return "hour_cost", true
+	}
+	return "", false
+}
+
+func (c Club) times(h *scan.Header) error {
+	if c.OpenTime != "" {
+		t, err := parse.DayTime(c.OpenTime)
+		if err != nil {
+			return settingError("open_time", c.OpenTime, errors.Unwrap(err))
+		}
+		h.OpenTime = t
+	}
+	if c.CloseTime != "" {
+		t, err := parse.DayTime(c.CloseTime)
+		if err != nil {
+			return settingError("close_time", c.CloseTime, errors.Unwrap(err))
+		}
+		h.CloseTime = t
+	}
+	return nil
+}
+
+func (c Club) location() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return nil, settingError("time_zone", c.TimeZone, UnknownTimeZone)
+	}
+	return location, nil
+}
+
+func (c Club) date() (time.Time, error) {
+	if c.Date == "" {
+		return time.Time{}, nil
+	}
+	if c.TimeZone == "" {
+		return time.Time{}, settingError("date", c.Date, DateWithoutTimeZone)
+	}
+	date, err := time.Parse(time.DateOnly, c.Date)
+	if err != nil {
+		return time.Time{}, settingError("date", c.Date, IncorrectDate)
+	}
+	return date, nil
+}
+
+func settingError(key, value string, err error) *parse.ParseError {
+	return &parse.ParseError{Col: 1, Raw: value, Field: key, Token: value, Err: err}
+}
+
+// locateSetting points a setting error at the line where the setting is.
+// A key repeated in a list, like the shift start, is found by its value.
+func locateSetting(src []byte, err error) error {
+	var perr *parse.ParseError
+	if !errors.As(err, &perr) {
+		return err
+	}
+	located := *perr
+	lines := strings.Split(string(src), "\n")
+	found := -1
+	for i, line := range lines {
+		key := strings.Index(line, strconv.Quote(perr.Field))
+		if key < 0 {
+			continue
+		}
+		if found < 0 {
+			found = i
+		}
+		if perr.Token != "" && strings.Contains(line[key:], strconv.Quote(perr.Token)) {
+			found = i
+			break
+		}
+	}
+	if found < 0 {
+		return &located
+	}
+
+	line := lines[found]
+	key := strings.Index(line, strconv.Quote(perr.Field))
+	located.Line, located.Raw = found+1, line
+	located.Col = key + 1
+	if located.Token == "" {
+		located.Token = value(line[key:])
+	}
+	if at := strings.Index(line[key:], located.Token); located.Token != "" && at >= 0 {
+		located.Col = key + at + 1
+	}
+	return &located
+}
+
+// value returns the value of the "key": value pair at the start of s.
+func value(s string) string {
+	_, v, _ := strings.Cut(s, ":")
+	v = strings.TrimSpace(v)
+	v = strings.TrimSuffix(v, ",")
+	return strings.Trim(v, `"`)
+}
+
+// locateOffset turns an error found at a byte offset of src into a
+// ParseError for the line containing it.
+func locateOffset(src []byte, offset int64, err error) error {
+	offset = min(max(offset, 0), int64(len(src)))
+	before := string(src[:offset])
+	start := strings.LastIndex(before, "\n") + 1
+	end := bytes.IndexByte(src[start:], '\n')
+	if end < 0 {
+		end = len(src) - start
+	}
+	line := string(src[start : start+end])
+	return &parse.ParseError{
+		Line:  strings.Count(before, "\n") + 1,
+		Col:   int(offset) - start + 1,
+		Raw:   line,
+		Field: "config",
+		Err:   err,
+	}
+}
+
+func decodeError(src []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// the offset is right after the offending character
+		return locateOffset(src, syntaxErr.Offset-1, fmt.Errorf("%v: %w", syntaxErr, IncorrectConfig))
+	case errors.As(err, &typeErr):
+		perr := settingError(typeErr.Field, "", fmt.Errorf("should be %v, got %s: %w", typeErr.Type, typeErr.Value, IncorrectConfig))
+		return locateSetting(src, perr)
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return locateOffset(src, int64(len(src)), fmt.Errorf("unexpected end of the config: %w", IncorrectConfig))
+	}
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		key, _ := strconv.Unquote(name)
+		return locateSetting(src, settingError(key, key, UnknownSetting))
+	}
+	return fmt.Errorf("config.Read: %w", err)
+}
@@ -0,0 +1,218 @@
+package config_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/config"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/shift"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestRead(t *testing.T) {
+	t.Run("full config", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{
+  "tables": 3,
+  "open_time": "09:00",
+  "close_time": "19:00",
+  "hour_cost": 10,
+  "queue_capacity": 1,
+  "time_zone": "UTC"
+}`))
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, club.Complete())
+		test.AssertEqual(t, *club.QueueCapacity, 1)
+	})
+
+	t.Run("partial config", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"hour_cost": 15}`))
+		test.AssertNoError(t, err)
+		test.AssertFalse(t, club.Complete())
+	})
+
+	cases := []struct {
+		name  string
+		input string
+		err   error
+		line  int
+		col   int
+		field string
+	}{
+		{"syntax error", "{\n  \"tables\": 3,\n}", config.IncorrectConfig, 3, 1, "config"},
+		{"wrong type", "{\n  \"hour_cost\": \"ten\"\n}", config.IncorrectConfig, 2, 17, "hour_cost"},
+		{"unknown setting", "{\n  \"tabels\": 3\n}", config.UnknownSetting, 2, 4, "tabels"},
+		{"unknown tariff class", "{\n  \"tariffs\": [{\"from\": \"18:00\", \"class\": \"vip\", \"hour_cost\": 20}]\n}", config.UnknownClass, 2, 43, "class"},
+		{"table in two classes", "{\n  \"table_classes\": [\n    {\"name\": \"vip\", \"tables\": [1], \"hour_cost\": 20},\n    {\"name\": \"pro\", \"tables\": [1], \"hour_cost\": 15}\n  ]\n}", config.TableInTwoClasses, 4, 15, "name"},
+		{"class table out of range", "{\n  \"tables\": 2,\n  \"table_classes\": [{\"name\": \"vip\", \"tables\": [3], \"hour_cost\": 20}]\n}", config.TableOutOfRange, 3, 31, "name"},
+		{"tariffs out of order", "{\n  \"tariffs\": [\n    {\"from\": \"18:00\", \"hour_cost\": 15},\n    {\"from\": \"12:00\", \"hour_cost\": 5}\n  ]\n}", config.TariffsOutOfOrder, 4, 15, "from"},
+		{"non positive tariff", "{\n  \"tariffs\": [{\"from\": \"18:00\", \"hour_cost\": 0}]\n}", parse.LessOrEqualZeroError, 2, 25, "from"},
+		{"non positive tables", "{\n  \"tables\": 0\n}", parse.LessOrEqualZeroError, 2, 13, "tables"},
+		{"incorrect time", "{\n  \"open_time\": \"9:00\"\n}", parse.IncorrectDayTimeFormat, 2, 17, "open_time"},
+		{"open after close", "{\n  \"open_time\": \"20:00\",\n  \"close_time\": \"19:00\"\n}", parse.OpenTimeIsAfterCloseTimeError, 3, 18, "close_time"},
+		{"negative queue", "{\n  \"queue_capacity\": -1\n}", config.IncorrectConfig, 2, 21, "queue_capacity"},
+		{"unknown time zone", "{\n  \"time_zone\": \"Mars/Olympus\"\n}", config.UnknownTimeZone, 2, 17, "time_zone"},
+		{"unknown charset", "{\n  \"client_names\": {\n    \"charset\": \"latin\"\n  }\n}", config.IncorrectConfig, 3, 17, "charset"},
+		{"incorrect shift start", "{\n  \"shifts\": [{\"start\": \"16\", \"operator\": \"anna\"}]\n}", parse.IncorrectDayTimeFormat, 2, 25, "start"},
+		{"shifts out of order", "{\n  \"shifts\": [\n    {\"start\": \"16:00\", \"operator\": \"anna\"},\n    {\"start\": \"09:00\", \"operator\": \"boris\"}\n  ]\n}", config.ShiftsOutOfOrder, 4, 16, "start"},
+		{"operator with spaces", "{\n  \"shifts\": [{\"start\": \"16:00\", \"operator\": \"anna k\"}]\n}", config.IncorrectOperatorName, 2, 46, "operator"},
+		{"empty", "", config.IncorrectConfig, 1, 1, "config"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := config.Read(strings.NewReader(c.input))
+			test.AssertError(t, err, c.err)
+
+			var perr *parse.ParseError
+			test.AssertTrue(t, errors.As(err, &perr))
+			test.AssertEqual(t, perr.Line, c.line)
+			test.AssertEqual(t, perr.Col, c.col)
+			test.AssertEqual(t, perr.Field, c.field)
+		})
+	}
+}
+
+func TestNamePolicy(t *testing.T) {
+	t.Run("task policy by default", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{}`))
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, club.NamePolicy(), parse.NamePolicy{})
+	})
+
+	t.Run("client names", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"client_names": {"charset": "unicode", "max_length": 16, "fold_case": true}}`))
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, club.NamePolicy(), parse.NamePolicy{Unicode: true, MaxLength: 16, FoldCase: true})
+	})
+}
+
+func TestShiftPlan(t *testing.T) {
+	club, err := config.Read(strings.NewReader(`{"shifts": [{"start": "09:00", "operator": "anna"}, {"start": "16:00", "operator": "boris"}]}`))
+	test.AssertNoError(t, err)
+
+	shifts := club.ShiftPlan()
+	test.AssertEqual(t, len(shifts), 2)
+	test.AssertEqual(t, shifts[1], shift.Shift{Start: store.NewDayTime(16, 0), Operator: "boris"})
+
+	club, err = config.Read(strings.NewReader(`{}`))
+	test.AssertNoError(t, err)
+	test.AssertEqual(t, len(club.ShiftPlan()), 0)
+}
+
+func TestTariffPlan(t *testing.T) {
+	club, err := config.Read(strings.NewReader(`{
+  "table_classes": [{"name": "vip", "tables": [1, 2], "hour_cost": 20}],
+  "tariffs": [
+    {"from": "18:00", "hour_cost": 15},
+    {"from": "18:00", "class": "vip", "hour_cost": 30}
+  ]
+}`))
+	test.AssertNoError(t, err)
+
+	plan := club.TariffPlan()
+	test.AssertEqual(t, len(plan.Classes), 1)
+	test.AssertEqual(t, plan.Classes[0].Name, "vip")
+	test.AssertEqual(t, plan.Tariffs[1], tariff.Tariff{From: store.NewDayTime(18, 0), Class: "vip", HourCost: 30})
+
+	t.Run("class tables are checked against the header", func(t *testing.T) {
+		_, err := club.Apply(scan.Header{TablesCount: 1, OpenTime: store.NewDayTime(9, 0), CloseTime: store.NewDayTime(19, 0), HourCost: 10})
+		test.AssertError(t, err, config.TableOutOfRange)
+	})
+
+	club, err = config.Read(strings.NewReader(`{}`))
+	test.AssertNoError(t, err)
+	test.AssertTrue(t, club.TariffPlan().IsZero())
+}
+
+func TestApply(t *testing.T) {
+	header := scan.Header{
+		TablesCount: 3,
+		OpenTime:    store.NewDayTime(9, 0),
+		CloseTime:   store.NewDayTime(19, 0),
+		HourCost:    10,
+	}
+
+	t.Run("overrides only the settings present", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"hour_cost": 15, "queue_capacity": 1}`))
+		test.AssertNoError(t, err)
+
+		got, err := club.Apply(header)
+		test.AssertNoError(t, err)
+
+		want := header
+		want.HourCost = 15
+		want.QueueCapacity = 1
+		test.AssertEqual(t, got, want)
+	})
+
+	t.Run("replaces the missing header", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10}`))
+		test.AssertNoError(t, err)
+
+		got, err := club.Apply(scan.Header{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, got, header)
+	})
+
+	t.Run("incomplete config without header", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"tables": 3}`))
+		test.AssertNoError(t, err)
+
+		_, err = club.Apply(scan.Header{})
+		test.AssertError(t, err, config.MissingSetting)
+	})
+
+	t.Run("override makes open time after close time", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"open_time": "20:00"}`))
+		test.AssertNoError(t, err)
+
+		_, err = club.Apply(header)
+		test.AssertError(t, err, parse.OpenTimeIsAfterCloseTimeError)
+	})
+
+	t.Run("time zone", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"time_zone": "Europe/Moscow"}`))
+		test.AssertNoError(t, err)
+
+		got, err := club.Apply(header)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, got.Zone().String(), "Europe/Moscow")
+	})
+
+	t.Run("club day", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"time_zone": "Europe/Berlin", "date": "2024-03-31"}`))
+		test.AssertNoError(t, err)
+
+		got, err := club.Apply(header)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, got.Clock().Day.String(), "2024-03-31 00:00:00 +0100 CET")
+	})
+
+	t.Run("overnight club with a time zone", func(t *testing.T) {
+		club, err := config.Read(strings.NewReader(`{"time_zone": "Europe/Berlin", "open_time": "22:00", "close_time": "06:00"}`))
+		test.AssertNoError(t, err)
+
+		got, err := club.Apply(header)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, got.Clock().Overnight)
+	})
+
+	t.Run("overnight club without a time zone", func(t *testing.T) {
+		_, err := config.Read(strings.NewReader(`{"open_time": "22:00", "close_time": "06:00"}`))
+		test.AssertError(t, err, parse.OpenTimeIsAfterCloseTimeError)
+	})
+
+	t.Run("date without a time zone", func(t *testing.T) {
+		_, err := config.Read(strings.NewReader(`{"date": "2024-03-31"}`))
+		test.AssertError(t, err, config.DateWithoutTimeZone)
+	})
+
+	t.Run("incorrect date", func(t *testing.T) {
+		_, err := config.Read(strings.NewReader(`{"time_zone": "UTC", "date": "31.03.2024"}`))
+		test.AssertError(t, err, config.IncorrectDate)
+	})
+}
@@ -0,0 +1,266 @@
+package dashboard
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+// logSize is the number of the last protocol lines the dashboard shows.
+const logSize = 6
+
+type TableState int
+
+const (
+	Free TableState = iota
+	Busy
+	// Reserved tables are held by the staff for a client, nobody else can
+	// sit down at them.
+	Reserved
+)
+
+func (s TableState) String() string {
+	switch s {
+	case Free:
+		return "free"
+	case Busy:
+		return "busy"
+	case Reserved:
+		return "reserved"
+	}
+	return "unknown"
+}
+
+// Table is a table on the map.
+type Table struct {
+	Number  int
+	State   TableState
+	Client  string
+	Elapsed time.Duration
+	Bill    float64
+}
+
+// View is what the dashboard shows at a moment.
+type View struct {
+	Time   store.DayTime
+	Tables []Table
+	Queue  []string
+	// Revenue is the money paid for finished sessions, Pending is the bills
+	// of the clients at the tables now.
+	Revenue float64
+	Pending float64
+	// Log holds the last protocol lines, the oldest first.
+	Log     []string
+	Prompt  string
+	Message string
+}
+
+// command is what a shortcut key asks the staff to type in.
+type command struct {
+	key  rune
+	name string
+	args string
+	// id is the input event the command serves, zero for reservations.
+	id int
+}
+
+var commands = []command{
+	{key: 'a', name: "arrive", args: "client", id: event.ArrivalEventId},
+	{key: 's', name: "sit", args: "client table", id: event.SitDownEventId},
+	{key: 'w', name: "wait", args: "client", id: event.WaitEventId},
+	{key: 'l', name: "leave", args: "client", id: event.LeaveEventId},
+	{key: 'p', name: "promo", args: "client promotion", id: event.PromotionEventId},
+	{key: 'r', name: "reserve", args: "table client"},
+}
+
+// Help lists the shortcut keys.
+func Help() string {
+	var b strings.Builder
+	for _, c := range commands {
+		fmt.Fprintf(&b, "%c %s  ", c.key, c.name)
+	}
+	b.WriteString("q quit")
+	return b.String()
+}
+
+// Dashboard serves the events the staff type in through the club service
+// and keeps what the front desk sees.
+type Dashboard struct {
+	// Parser reads the events typed in, its Clock has to be the one now
+	// tells the time on.
+	Parser  parse.Parser
+	club    *service.ComputerClub
+	service *service.Service
+	now     func() store.DayTime
+	closed  bool
+	command *command
+	input   []rune
+	log     []string
+	message string
+}
+
+// New returns a dashboard of club, now tells the time of the events.
+func New(club *service.ComputerClub, now func() store.DayTime) *Dashboard {
+	return &Dashboard{
+		club:    club,
+		service: service.NewService(club),
+		now:     now,
+	}
+}
+
+// Tick closes the club once its close time has come, sending away and
+// billing everybody in it.
+func (d *Dashboard) Tick() error {
+	if d.closed || d.now().Compare(d.club.CloseTime.Time) == -1 {
+		return nil
+	}
+	events, err := d.service.Close()
+	if err != nil {
+		return fmt.Errorf("Dashboard.Tick: %w", err)
+	}
+	for _, e := range events {
+		d.print(&e)
+	}
+	d.closed = true
+	return nil
+}
+
+// Key handles a key press and reports whether the staff asked to quit.
+// A shortcut key starts a command, its arguments are typed in and Enter
+// runs it, Escape cancels it.
+func (d *Dashboard) Key(r rune) (quit bool) {
+	if d.command == nil {
+		if r == 'q' {
+			return true
+		}
+		for i, c := range commands {
+			if c.key == r {
+				d.command, d.message = &commands[i], ""
+			}
+		}
+		return false
+	}
+
+	switch r {
+	case '\r', '\n':
+		d.run(*d.command, strings.TrimSpace(string(d.input)))
+		d.command, d.input = nil, nil
+	case 0x1b:
+		d.command, d.input = nil, nil
+	case 0x7f, '\b':
+		if len(d.input) > 0 {
+			d.input = d.input[:len(d.input)-1]
+		}
+	default:
+		if r >= ' ' {
+			d.input = append(d.input, r)
+		}
+	}
+	return false
+}
+
+func (d *Dashboard) run(c command, args string) {
+	if err := d.Tick(); err != nil {
+		d.message = err.Error()
+		return
+	}
+	if c.id == 0 {
+		d.reserve(args)
+		return
+	}
+
+	// the line goes through the parser, so that the staff can't type in
+	// what the input file couldn't have
+	e, err := d.Parser.InputEvent(fmt.Sprintf("%s %d %s", d.now(), c.id, args))
+	if err != nil {
+		d.message = err.Error()
+		return
+	}
+
+	out := d.service.ServeEvent(e)
+	d.print(e)
+	if !event.IsEmpty(out) {
+		d.print(out)
+	}
+	if errEvent, ok := out.(*event.ErrorEvent); ok {
+		d.message = errEvent.Err().Error()
+	}
+}
+
+// reserve holds a table for a client, a table given alone is released.
+func (d *Dashboard) reserve(args string) {
+	tableArg, client, _ := strings.Cut(args, " ")
+	table, err := parse.TablesCount(tableArg)
+	if err != nil || table > d.club.ComputerCount {
+		d.message = fmt.Sprintf("no table %q", tableArg)
+		return
+	}
+	if client == "" {
+		d.club.CancelReservation(table)
+		return
+	}
+	if client, err = d.Parser.Names.ClientName(client); err != nil {
+		d.message = err.Error()
+		return
+	}
+	if err := d.club.Reserve(table, client); err != nil {
+		d.message = fmt.Sprintf("table %d is busy", table)
+	}
+}
+
+func (d *Dashboard) print(e any) {
+	d.log = append(d.log, fmt.Sprint(e))
+	if len(d.log) > logSize {
+		d.log = d.log[len(d.log)-logSize:]
+	}
+}
+
+// View returns the state of the club at the current time.
+func (d *Dashboard) View() (View, error) {
+	now := d.now()
+	v := View{
+		Time:    now,
+		Tables:  make([]Table, d.club.ComputerCount),
+		Queue:   d.club.Waiting(),
+		Log:     slices.Clone(d.log),
+		Message: d.message,
+	}
+	if d.command != nil {
+		v.Prompt = fmt.Sprintf("%s %s: %s", d.command.name, d.command.args, string(d.input))
+	}
+
+	infos, err := d.club.TablesInfo()
+	if err != nil {
+		return v, fmt.Errorf("Dashboard.View: %w", err)
+	}
+	for i, info := range infos {
+		v.Tables[i] = Table{Number: info.Number}
+		if client, ok := d.club.Reservation(info.Number); ok {
+			v.Tables[i].State, v.Tables[i].Client = Reserved, client
+		}
+		v.Revenue += info.Profit
+	}
+
+	clients, err := d.club.Clients()
+	if err != nil {
+		return v, fmt.Errorf("Dashboard.View: %w", err)
+	}
+	for _, client := range clients {
+		if client.Table == 0 {
+			continue
+		}
+		table := &v.Tables[client.Table-1]
+		table.State = Busy
+		table.Client = client.Name
+		table.Elapsed = client.PlayingTime(now)
+		table.Bill = client.Payment(now, d.club.HourCost(client.Table, client.PlayingSince))
+		v.Pending += table.Bill
+	}
+	return v, nil
+}
@@ -0,0 +1,206 @@
+package dashboard_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/dashboard"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+// headless drives a dashboard with keys and renders it without a terminal.
+type headless struct {
+	t     *testing.T
+	now   store.DayTime
+	board *dashboard.Dashboard
+}
+
+func newHeadless(t *testing.T, tables int) *headless {
+	h := &headless{t: t, now: store.NewDayTime(9, 0)}
+	club := service.NewComputerClub(tables, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	h.board = dashboard.New(club, func() store.DayTime { return h.now })
+	return h
+}
+
+func (h *headless) at(hour, minute int) *headless {
+	h.now = store.NewDayTime(hour, minute)
+	return h
+}
+
+func (h *headless) keys(s string) bool {
+	for _, r := range s {
+		if h.board.Key(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *headless) view() dashboard.View {
+	v, err := h.board.View()
+	test.AssertNoError(h.t, err)
+	return v
+}
+
+func (h *headless) screen(width int) string {
+	return dashboard.Render(h.view(), width).String()
+}
+
+func TestDashboard(t *testing.T) {
+	t.Run("keys issue events", func(t *testing.T) {
+		h := newHeadless(t, 3)
+		h.keys("aanna\r")
+		h.keys("sanna 2\r")
+		h.at(9, 10).keys("abob\r")
+		h.keys("sbob 3\r")
+		h.keys("acarl\r")
+		h.keys("wcarl\r")
+
+		v := h.at(10, 30).view()
+		test.AssertEqual(t, v.Tables[0].State, dashboard.Free)
+		test.AssertEqual(t, v.Tables[1].State, dashboard.Busy)
+		test.AssertEqual(t, v.Tables[1].Client, "anna")
+		test.AssertEqual(t, v.Tables[1].Elapsed, 90*time.Minute)
+		test.AssertEqual(t, v.Tables[1].Bill, 20.0)
+		test.AssertEqual(t, v.Pending, 40.0)
+		test.AssertEqual(t, v.Message, "ICanWaitNoLonger!")
+		test.AssertEqual(t, v.Log[len(v.Log)-1], "09:10 13 ICanWaitNoLonger!")
+
+		h.keys("lanna\r")
+		v = h.view()
+		test.AssertEqual(t, v.Tables[1].State, dashboard.Free)
+		test.AssertEqual(t, v.Revenue, 20.0)
+		test.AssertEqual(t, v.Pending, 20.0)
+	})
+
+	t.Run("queue and promotion", func(t *testing.T) {
+		h := newHeadless(t, 1)
+		h.keys("aanna\rsanna 1\rabob\rwbob\r")
+		test.AssertEqual(t, strings.Join(h.view().Queue, ","), "bob")
+
+		h.at(11, 0).keys("lanna\r")
+		v := h.view()
+		test.AssertEqual(t, len(v.Queue), 0)
+		test.AssertEqual(t, v.Tables[0].Client, "bob")
+		test.AssertEqual(t, v.Log[len(v.Log)-1], "11:00 12 bob 1")
+	})
+
+	t.Run("reservations", func(t *testing.T) {
+		h := newHeadless(t, 2)
+		h.keys("r2 anna\r")
+		v := h.view()
+		test.AssertEqual(t, v.Tables[1].State, dashboard.Reserved)
+		test.AssertEqual(t, v.Tables[1].Client, "anna")
+
+		h.keys("abob\rsbob 2\r")
+		test.AssertEqual(t, h.view().Message, "PlaceIsBusy")
+		h.keys("wbob\r")
+		test.AssertEqual(t, strings.Join(h.view().Queue, ","), "")
+		test.AssertEqual(t, h.view().Message, "ICanWaitNoLonger!")
+
+		h.keys("aanna\rsanna 2\r")
+		test.AssertEqual(t, h.view().Tables[1].State, dashboard.Busy)
+
+		h.keys("r2 carl\r")
+		test.AssertEqual(t, h.view().Message, "table 2 is busy")
+		h.keys("r5 carl\r")
+		test.AssertEqual(t, h.view().Message, `no table "5"`)
+		h.keys("r1 Carl!\r")
+		test.AssertTrue(t, strings.Contains(h.view().Message, "incorrect client name format"))
+
+		h.keys("r1 carl\rr1\r")
+		test.AssertEqual(t, h.view().Tables[0].State, dashboard.Free)
+	})
+
+	t.Run("club closes at the close time", func(t *testing.T) {
+		h := newHeadless(t, 1)
+		h.keys("aanna\rsanna 1\r")
+		test.AssertNoError(t, h.at(18, 59).board.Tick())
+		test.AssertEqual(t, h.view().Tables[0].State, dashboard.Busy)
+
+		test.AssertNoError(t, h.at(19, 30).board.Tick())
+		v := h.view()
+		test.AssertEqual(t, v.Tables[0].State, dashboard.Free)
+		test.AssertEqual(t, v.Revenue, 100.0)
+		test.AssertEqual(t, v.Log[len(v.Log)-1], "19:00 11 anna")
+
+		h.keys("abob\r")
+		test.AssertEqual(t, h.view().Message, "NotOpenYet")
+	})
+
+	t.Run("events are read with the parser of the club", func(t *testing.T) {
+		h := newHeadless(t, 1)
+		h.board.Parser = parse.Parser{Names: parse.NamePolicy{FoldCase: true}}
+		h.keys("aAnna\r")
+		test.AssertEqual(t, h.view().Log[0], "09:00 1 anna")
+	})
+
+	t.Run("typing, cancel and quit", func(t *testing.T) {
+		h := newHeadless(t, 1)
+		h.keys("aann")
+		test.AssertEqual(t, h.view().Prompt, "arrive client: ann")
+		h.keys("\x7f\x7fx")
+		test.AssertEqual(t, h.view().Prompt, "arrive client: ax")
+		h.keys("\x1b")
+		test.AssertEqual(t, h.view().Prompt, "")
+		test.AssertEqual(t, len(h.view().Log), 0)
+
+		h.keys("aAnna!\r")
+		test.AssertTrue(t, strings.Contains(h.view().Message, "incorrect client name format"))
+		test.AssertEqual(t, len(h.view().Log), 0)
+
+		test.AssertFalse(t, h.keys("x"))
+		test.AssertTrue(t, h.keys("q"))
+	})
+
+	t.Run("headless screen", func(t *testing.T) {
+		h := newHeadless(t, 3)
+		h.keys("aanna\rsanna 1\rr3 bob\r")
+		h.at(9, 45).keys("sbo")
+
+		want := `Computer club  09:45    revenue 0  at tables 10
+
+[1] busy            [2] free            [3] reserved
+ anna                                    for bob
+ 00:45  10
+
+Queue: empty
+
+09:00 1 anna
+09:00 2 anna 1
+
+
+
+
+
+a arrive  s sit  w wait  l leave  p promo  r reserve  q quit
+> sit client table: bo
+`
+		test.AssertEqual(t, h.screen(60), want)
+	})
+
+	t.Run("narrow screen wraps the map", func(t *testing.T) {
+		h := newHeadless(t, 3)
+		screen := h.screen(40)
+		test.AssertTrue(t, strings.Contains(screen, "[1] free            [2] free\n\n\n\n[3] free\n"))
+	})
+}
+
+func TestTerminal(t *testing.T) {
+	h := newHeadless(t, 2)
+	h.keys("aanna\rsanna 1\r")
+
+	buf := &strings.Builder{}
+	test.AssertNoError(t, dashboard.Terminal{W: buf}.Draw(dashboard.Render(h.view(), 80)))
+
+	test.AssertTrue(t, strings.HasPrefix(buf.String(), "\x1b[H\x1b[2J"))
+	test.AssertTrue(t, strings.Contains(buf.String(), "\x1b[1;31m[1] busy"))
+	test.AssertTrue(t, strings.Contains(buf.String(), "\x1b[1;32m[2] free"))
+	test.AssertFalse(t, strings.Contains(strings.ReplaceAll(buf.String(), "\r\n", ""), "\n"))
+}
@@ -0,0 +1,155 @@
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// cellWidth is the width of a table on the map, with the gap after it.
+const cellWidth = 20
+
+type Style int
+
+const (
+	Plain Style = iota
+	Title
+	FreeTable
+	BusyTable
+	ReservedTable
+	Warning
+)
+
+// Span is a piece of a line drawn in one style.
+type Span struct {
+	Text  string
+	Style Style
+}
+
+type Line []Span
+
+func (l Line) String() string {
+	var b strings.Builder
+	for _, span := range l {
+		b.WriteString(span.Text)
+	}
+	return b.String()
+}
+
+// Frame is a rendered view, a line per terminal row.
+type Frame []Line
+
+// String is the frame without styles, as a headless terminal would show it.
+func (f Frame) String() string {
+	var b strings.Builder
+	for _, line := range f {
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Render lays v out for a terminal width columns wide.
+func Render(v View, width int) Frame {
+	var f Frame
+	f = append(f,
+		Line{{fmt.Sprintf("Computer club  %s", v.Time), Title}, {fmt.Sprintf("    revenue %.f  at tables %.f", v.Revenue, v.Pending), Plain}},
+		nil,
+	)
+
+	perRow := max(1, width/cellWidth)
+	for start := 0; start < len(v.Tables); start += perRow {
+		row := v.Tables[start:min(start+perRow, len(v.Tables))]
+		var top, middle, bottom Line
+		for _, t := range row {
+			style := tableStyle(t.State)
+			top = append(top, Span{pad(fmt.Sprintf("[%d] %s", t.Number, t.State), cellWidth), style})
+			switch t.State {
+			case Busy:
+				middle = append(middle, Span{pad(" "+t.Client, cellWidth), Plain})
+				bottom = append(bottom, Span{pad(fmt.Sprintf(" %s  %.f", elapsed(t.Elapsed), t.Bill), cellWidth), Plain})
+			case Reserved:
+				middle = append(middle, Span{pad(" for "+t.Client, cellWidth), Plain})
+				bottom = append(bottom, Span{pad("", cellWidth), Plain})
+			default:
+				middle = append(middle, Span{pad("", cellWidth), Plain})
+				bottom = append(bottom, Span{pad("", cellWidth), Plain})
+			}
+		}
+		f = append(f, top, middle, bottom, nil)
+	}
+
+	queue := "empty"
+	if len(v.Queue) > 0 {
+		queue = strings.Join(v.Queue, ", ")
+	}
+	f = append(f, Line{{"Queue: ", Title}, {queue, Plain}}, nil)
+
+	for _, line := range v.Log {
+		f = append(f, Line{{line, Plain}})
+	}
+	for range logSize - len(v.Log) {
+		f = append(f, nil)
+	}
+	f = append(f, nil, Line{{Help(), Plain}}, Line{{"> " + v.Prompt, Plain}})
+	if v.Message != "" {
+		f = append(f, Line{{v.Message, Warning}})
+	}
+	return f
+}
+
+func tableStyle(s TableState) Style {
+	switch s {
+	case Busy:
+		return BusyTable
+	case Reserved:
+		return ReservedTable
+	}
+	return FreeTable
+}
+
+// elapsed prints d as HH:MM like the protocol prints the table working time.
+func elapsed(d time.Duration) string {
+	hours := int(d.Hours())
+	return fmt.Sprintf("%02d:%02d", hours, int(d.Minutes())-60*hours)
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return string([]rune(s)[:width-1]) + " "
+}
+
+var ansi = map[Style]string{
+	Title:         "\x1b[1m",
+	FreeTable:     "\x1b[1;32m",
+	BusyTable:     "\x1b[1;31m",
+	ReservedTable: "\x1b[1;33m",
+	Warning:       "\x1b[31m",
+}
+
+// Terminal draws frames full-screen on an ANSI terminal in raw mode.
+type Terminal struct {
+	W io.Writer
+}
+
+func (t Terminal) Draw(f Frame) error {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	for _, line := range f {
+		for _, span := range line {
+			if code, ok := ansi[span.Style]; ok {
+				b.WriteString(code + span.Text + "\x1b[0m")
+			} else {
+				b.WriteString(span.Text)
+			}
+		}
+		// raw mode doesn't return the carriage on a line feed
+		b.WriteString("\r\n")
+	}
+	_, err := io.WriteString(t.W, b.String())
+	return err
+}
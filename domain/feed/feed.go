@@ -0,0 +1,72 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+)
+
+// DefaultBuffer is the number of events kept for a client that reads
+// slower than the club generates them.
+const DefaultBuffer = 64
+
+// Handler streams the events published on Bus as Server-Sent Events, an
+// event.Record in JSON per message:
+//
+//	data: {"time":"12:33","id":12,"client":"client4","table":1}
+//
+// A client that falls behind loses the oldest events and gets a "lag"
+// message with the number of the events lost so far.
+type Handler struct {
+	Bus    *bus.Bus[event.Event]
+	Buffer int
+}
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	buffer := h.Buffer
+	if buffer == 0 {
+		buffer = DefaultBuffer
+	}
+
+	sub := h.Bus.Subscribe(buffer)
+	defer sub.Unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var reported uint64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if dropped := sub.Dropped(); dropped != reported {
+				reported = dropped
+				if _, err := fmt.Fprintf(w, "event: lag\ndata: {\"dropped\":%d}\n\n", dropped); err != nil {
+					return
+				}
+			}
+			data, err := json.Marshal(event.NewRecord(e))
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package feed_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/feed"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestHandler(t *testing.T) {
+	t.Run("streams the generated events as JSON", func(t *testing.T) {
+		events := bus.New[event.Event]()
+		server := httptest.NewServer(feed.Handler{Bus: events})
+		defer server.Close()
+
+		resp, err := server.Client().Get(server.URL)
+		test.AssertNoError(t, err)
+		defer resp.Body.Close()
+		test.AssertEqual(t, resp.Header.Get("Content-Type"), "text/event-stream")
+		test.AssertEqual(t, events.Subscribers(), 1)
+
+		file, err := os.Open("../../tests/basic.txt")
+		test.AssertNoError(t, err)
+		defer file.Close()
+		p := &scan.Processor{Events: events}
+		_, err = p.ScanInputData(file, &strings.Builder{})
+		test.AssertNoError(t, err)
+
+		want := []string{
+			`data: {"time":"08:48","id":13,"error":"NotOpenYet"}`,
+			`data: {"time":"09:52","id":13,"error":"ICanWaitNoLonger!"}`,
+			`data: {"time":"11:35","id":13,"error":"PlaceIsBusy"}`,
+			`data: {"time":"12:33","id":12,"client":"client4","table":1}`,
+			`data: {"time":"19:00","id":11,"client":"client3"}`,
+		}
+		scanner := bufio.NewScanner(resp.Body)
+		for _, line := range want {
+			test.AssertTrue(t, scanner.Scan())
+			test.AssertEqual(t, scanner.Text(), line)
+			test.AssertTrue(t, scanner.Scan())
+			test.AssertEqual(t, scanner.Text(), "")
+		}
+	})
+
+	t.Run("unsubscribes when the client goes away", func(t *testing.T) {
+		events := bus.New[event.Event]()
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+
+		done := make(chan struct{})
+		go func() {
+			feed.Handler{Bus: events}.ServeHTTP(httptest.NewRecorder(), req)
+			close(done)
+		}()
+		for events.Subscribers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+		test.AssertEqual(t, events.Subscribers(), 0)
+	})
+
+	t.Run("slow client is told how many events it lost", func(t *testing.T) {
+		events := bus.New[event.Event]()
+		w := &slowWriter{ResponseRecorder: httptest.NewRecorder(), writing: make(chan struct{}), proceed: make(chan struct{})}
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+
+		done := make(chan struct{})
+		go func() {
+			feed.Handler{Bus: events, Buffer: 2}.ServeHTTP(w, req)
+			close(done)
+		}()
+		for events.Subscribers() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		events.Publish(event.NewOutLeaveEvent(test.DummyDayTime, "a"))
+		<-w.writing
+		for _, client := range []string{"b", "c", "d", "e", "f"} {
+			events.Publish(event.NewOutLeaveEvent(test.DummyDayTime, client))
+		}
+		close(w.proceed)
+
+		for !strings.Contains(w.body(), `"client":"f"`) {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+
+		test.AssertEqual(t, w.body(), `data: {"time":"00:00","id":11,"client":"a"}`+"\n\n"+
+			"event: lag\n"+`data: {"dropped":3}`+"\n\n"+
+			`data: {"time":"00:00","id":11,"client":"e"}`+"\n\n"+
+			`data: {"time":"00:00","id":11,"client":"f"}`+"\n\n")
+	})
+}
+
+// slowWriter stops at the first write until the test lets it go on.
+type slowWriter struct {
+	*httptest.ResponseRecorder
+	mu      sync.Mutex
+	once    sync.Once
+	writing chan struct{}
+	proceed chan struct{}
+}
+
+func (w *slowWriter) Write(b []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.writing)
+		<-w.proceed
+	})
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseRecorder.Write(b)
+}
+
+func (w *slowWriter) body() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ResponseRecorder.Body.String()
+}
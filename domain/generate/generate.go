@@ -0,0 +1,318 @@
+package generate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+var IncorrectConfig = fmt.Errorf("incorrect generator config")
+
+type SessionDistribution int
+
+const (
+	Exponential SessionDistribution = iota
+	Uniform
+	Fixed
+)
+
+func ParseSessionDistribution(s string) (SessionDistribution, error) {
+	switch s {
+	case "exp", "exponential":
+		return Exponential, nil
+	case "uniform":
+		return Uniform, nil
+	case "fixed":
+		return Fixed, nil
+	}
+	return 0, fmt.Errorf("generate.ParseSessionDistribution: unknown distribution %q: %w", s, IncorrectConfig)
+}
+
+type Config struct {
+	Tables    int
+	OpenTime  store.DayTime
+	CloseTime store.DayTime
+	HourCost  float64
+
+	// ArrivalRate is the mean number of clients arriving per hour.
+	ArrivalRate float64
+	SessionDist SessionDistribution
+	SessionMean time.Duration
+	// WaitPropensity is the probability that a client who finds every table
+	// busy asks to wait instead of leaving straight away.
+	WaitPropensity float64
+	// ErrorRate is the probability that an arrival is accompanied by an event
+	// which violates a business rule and makes the club answer with an error.
+	ErrorRate float64
+	Seed      int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Tables:         3,
+		OpenTime:       store.NewDayTime(9, 0),
+		CloseTime:      store.NewDayTime(19, 0),
+		HourCost:       10,
+		ArrivalRate:    6,
+		SessionDist:    Exponential,
+		SessionMean:    90 * time.Minute,
+		WaitPropensity: 0.5,
+	}
+}
+
+func (c Config) Validate() error {
+	switch {
+	case c.Tables <= 0:
+		return fmt.Errorf("Config.Validate: tables count should be positive: %w", IncorrectConfig)
+	case c.OpenTime.Compare(c.CloseTime.Time) >= 0:
+		return fmt.Errorf("Config.Validate: open time should be before close time: %w", IncorrectConfig)
+	case c.HourCost <= 0:
+		return fmt.Errorf("Config.Validate: hour cost should be positive: %w", IncorrectConfig)
+	case c.ArrivalRate <= 0:
+		return fmt.Errorf("Config.Validate: arrival rate should be positive: %w", IncorrectConfig)
+	case c.SessionMean < time.Minute:
+		return fmt.Errorf("Config.Validate: mean session should be at least a minute: %w", IncorrectConfig)
+	case c.WaitPropensity < 0 || c.WaitPropensity > 1:
+		return fmt.Errorf("Config.Validate: wait propensity should be in [0, 1]: %w", IncorrectConfig)
+	case c.ErrorRate < 0 || c.ErrorRate > 1:
+		return fmt.Errorf("Config.Validate: error rate should be in [0, 1]: %w", IncorrectConfig)
+	}
+	return nil
+}
+
+// Generate writes a complete club log for the given config: the three header
+// lines followed by events in non-decreasing time order. The same config,
+// seed included, always produces the same log.
+func Generate(w io.Writer, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("generate.Generate: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	g := newGenerator(cfg, bw)
+
+	fmt.Fprintln(bw, cfg.Tables)
+	fmt.Fprintln(bw, cfg.OpenTime, cfg.CloseTime)
+	fmt.Fprintln(bw, cfg.HourCost)
+
+	g.run()
+	return bw.Flush()
+}
+
+// generator tracks the club state the way ComputerClub would, so that every
+// event it emits is valid unless it was injected as an error on purpose.
+// Time is measured in minutes since OpenTime.
+type generator struct {
+	cfg Config
+	w   io.Writer
+	rnd *rand.Rand
+
+	tables   []string
+	seatedAt map[string]int
+	present  map[string]bool
+	queue    []string
+	leaving  *queue.Departures[float64]
+	clients  int
+	ghosts   int
+}
+
+func newGenerator(cfg Config, w io.Writer) *generator {
+	return &generator{
+		cfg:      cfg,
+		w:        w,
+		rnd:      rand.New(rand.NewSource(cfg.Seed)),
+		tables:   make([]string, cfg.Tables+1),
+		seatedAt: map[string]int{},
+		present:  map[string]bool{},
+		leaving:  queue.NewDepartures(func(a, b float64) bool { return a < b }),
+	}
+}
+
+func (g *generator) run() {
+	end := g.cfg.CloseTime.Sub(g.cfg.OpenTime.Time).Minutes()
+	nextArrival := g.interarrival()
+
+	for {
+		nextLeave := math.Inf(1)
+		if g.leaving.Len() > 0 {
+			nextLeave, _ = g.leaving.Next()
+		}
+
+		now := math.Min(nextArrival, nextLeave)
+		if now >= end {
+			return
+		}
+
+		if nextLeave <= nextArrival {
+			g.leave(g.leaving.Pop())
+			continue
+		}
+
+		g.arrive(now)
+		nextArrival = now + g.interarrival()
+	}
+}
+
+func (g *generator) arrive(now float64) {
+	g.clients++
+	client := "client_" + letters(g.clients)
+	g.emit(event.NewArrivalEvent(g.at(now), client))
+	g.present[client] = true
+
+	if g.rnd.Float64() < g.cfg.ErrorRate {
+		g.injectError(now)
+	}
+
+	if table, ok := g.freeTable(); ok {
+		g.emit(event.NewSitDownEvent(g.at(now), client, table))
+		g.seat(now, client, table)
+		return
+	}
+
+	if g.rnd.Float64() >= g.cfg.WaitPropensity {
+		g.emit(event.NewLeaveEvent(g.at(now), client))
+		delete(g.present, client)
+		return
+	}
+
+	g.emit(event.NewWaitEvent(g.at(now), client))
+	if len(g.queue) >= g.cfg.Tables {
+		// the club sends the client away itself
+		delete(g.present, client)
+		return
+	}
+	g.queue = append(g.queue, client)
+}
+
+func (g *generator) leave(now float64, client string) {
+	g.emit(event.NewLeaveEvent(g.at(now), client))
+	delete(g.present, client)
+
+	table := g.seatedAt[client]
+	delete(g.seatedAt, client)
+	g.tables[table] = ""
+
+	if len(g.queue) == 0 {
+		return
+	}
+
+	// the club seats the first waiting client itself, no input event needed
+	waiting := g.queue[0]
+	g.queue = g.queue[1:]
+	g.seat(now, waiting, table)
+}
+
+func (g *generator) seat(now float64, client string, table int) {
+	g.tables[table] = client
+	g.seatedAt[client] = table
+	g.leaving.Push(now+g.session(), client)
+}
+
+func (g *generator) injectError(now float64) {
+	t := g.at(now)
+	seated := g.seatedClients()
+
+	switch g.rnd.Intn(5) {
+	case 0:
+		// YouShallNotPass
+		g.emit(event.NewArrivalEvent(t, g.anyPresent()))
+	case 1:
+		// ClientUnknown
+		g.emit(event.NewSitDownEvent(t, g.ghost(), 1+g.rnd.Intn(g.cfg.Tables)))
+	case 2:
+		// ClientUnknown
+		g.emit(event.NewLeaveEvent(t, g.ghost()))
+	case 3:
+		// PlaceIsBusy
+		if len(seated) == 0 {
+			g.emit(event.NewWaitEvent(t, g.ghost()))
+			return
+		}
+		client := seated[g.rnd.Intn(len(seated))]
+		g.emit(event.NewSitDownEvent(t, client, g.seatedAt[client]))
+	case 4:
+		// ICanWaitNoLonger while there are free tables, ClientUnknown otherwise
+		if _, ok := g.freeTable(); ok {
+			g.emit(event.NewWaitEvent(t, g.anyPresent()))
+			return
+		}
+		g.emit(event.NewWaitEvent(t, g.ghost()))
+	}
+}
+
+func (g *generator) freeTable() (int, bool) {
+	var free []int
+	for i := 1; i <= g.cfg.Tables; i++ {
+		if g.tables[i] == "" {
+			free = append(free, i)
+		}
+	}
+	if len(free) == 0 {
+		return 0, false
+	}
+	return free[g.rnd.Intn(len(free))], true
+}
+
+func (g *generator) seatedClients() []string {
+	var seated []string
+	for i := 1; i <= g.cfg.Tables; i++ {
+		if g.tables[i] != "" {
+			seated = append(seated, g.tables[i])
+		}
+	}
+	return seated
+}
+
+// anyPresent returns the most recently arrived client, who is always present
+// at the moment errors are injected.
+func (g *generator) anyPresent() string {
+	return "client_" + letters(g.clients)
+}
+
+func (g *generator) ghost() string {
+	g.ghosts++
+	return "ghost_" + letters(g.ghosts)
+}
+
+// letters encodes n > 0 as a bijective base-26 number (a, b, ..., z, aa, ab, ...),
+// which keeps generated names within the a..z client name alphabet.
+func letters(n int) string {
+	var b []byte
+	for ; n > 0; n = (n - 1) / 26 {
+		b = append([]byte{byte('a' + (n-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+func (g *generator) interarrival() float64 {
+	return g.rnd.ExpFloat64() * 60 / g.cfg.ArrivalRate
+}
+
+func (g *generator) session() float64 {
+	mean := g.cfg.SessionMean.Minutes()
+	var d float64
+	switch g.cfg.SessionDist {
+	case Exponential:
+		d = g.rnd.ExpFloat64() * mean
+	case Uniform:
+		d = mean * (0.5 + g.rnd.Float64())
+	case Fixed:
+		d = mean
+	}
+	return math.Max(d, 1)
+}
+
+func (g *generator) at(minutes float64) store.DayTime {
+	return store.DayTime{Time: g.cfg.OpenTime.Add(time.Duration(minutes) * time.Minute)}
+}
+
+func (g *generator) emit(e fmt.Stringer) {
+	fmt.Fprintln(g.w, e)
+}
@@ -0,0 +1,68 @@
+package generate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/generate"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Run("same seed gives same log", func(t *testing.T) {
+		cfg := generate.DefaultConfig()
+		cfg.Seed = 42
+		cfg.ErrorRate = 0.3
+
+		first, second := &strings.Builder{}, &strings.Builder{}
+		test.AssertNoError(t, generate.Generate(first, cfg))
+		test.AssertNoError(t, generate.Generate(second, cfg))
+		test.AssertEqual(t, first.String(), second.String())
+
+		cfg.Seed = 43
+		third := &strings.Builder{}
+		test.AssertNoError(t, generate.Generate(third, cfg))
+		test.AssertTrue(t, first.String() != third.String())
+	})
+
+	t.Run("log without injected errors is processed without error events", func(t *testing.T) {
+		for seed := int64(0); seed < 20; seed++ {
+			cfg := generate.DefaultConfig()
+			cfg.Seed = seed
+			cfg.ArrivalRate = 20
+
+			out := runGenerated(t, cfg)
+			test.AssertFalse(t, strings.Contains(out, " 13 "))
+		}
+	})
+
+	t.Run("injected errors are reported by the club", func(t *testing.T) {
+		cfg := generate.DefaultConfig()
+		cfg.ErrorRate = 1
+
+		out := runGenerated(t, cfg)
+		test.AssertTrue(t, strings.Contains(out, " 13 "))
+	})
+
+	t.Run("incorrect config", func(t *testing.T) {
+		cfg := generate.DefaultConfig()
+		cfg.WaitPropensity = 2
+
+		err := generate.Generate(&strings.Builder{}, cfg)
+		test.AssertError(t, err, generate.IncorrectConfig)
+	})
+}
+
+func runGenerated(t testing.TB, cfg generate.Config) string {
+	t.Helper()
+	log := &strings.Builder{}
+	test.AssertNoError(t, generate.Generate(log, cfg))
+
+	out := &strings.Builder{}
+	line, err := scan.ScanInputData(strings.NewReader(log.String()), out)
+	if err != nil {
+		t.Fatalf("generated log rejected at line %q: %q", line, err)
+	}
+	return out.String()
+}
@@ -0,0 +1,279 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/config"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+var UnknownLang = fmt.Errorf("unknown language")
+
+type Lang string
+
+const (
+	English Lang = "en"
+	Russian Lang = "ru"
+)
+
+func ParseLang(s string) (Lang, error) {
+	switch Lang(s) {
+	case English, Russian:
+		return Lang(s), nil
+	}
+	return "", fmt.Errorf("i18n.ParseLang: %q: %w", s, UnknownLang)
+}
+
+type entry struct {
+	err  error
+	text map[Lang]string
+}
+
+// catalogue is ordered so that lookups are deterministic: the first entry
+// found in the error chain wins.
+var catalogue = []entry{
+	{service.YouShallNotPass, map[Lang]string{
+		English: "the client is already in the club",
+		Russian: "клиент уже находится в клубе",
+	}},
+	{service.NotOpenYet, map[Lang]string{
+		English: "the club is closed at this time",
+		Russian: "клуб в это время закрыт",
+	}},
+	{service.PlaceIsBusy, map[Lang]string{
+		English: "the table is taken",
+		Russian: "стол занят",
+	}},
+	{service.ClientUnknown, map[Lang]string{
+		English: "the client is not in the club",
+		Russian: "клиента нет в клубе",
+	}},
+	{service.ICanWaitNoLonger, map[Lang]string{
+		English: "there is a free table, no need to wait",
+		Russian: "есть свободный стол, ждать не нужно",
+	}},
+	{service.IncorrectTableNumber, map[Lang]string{
+		English: "there is no table with this number",
+		Russian: "стола с таким номером нет",
+	}},
+	{service.EventUnknown, map[Lang]string{
+		English: "there is no event with this number to correct",
+		Russian: "события с таким номером для исправления нет",
+	}},
+	{service.CorrectionRejected, map[Lang]string{
+		English: "the correction would make the events incorrect",
+		Russian: "после исправления события стали бы некорректными",
+	}},
+	{scan.EventTimeIsBeforePrevious, map[Lang]string{
+		English: "the event happened before the previous one",
+		Russian: "событие произошло раньше предыдущего",
+	}},
+	{parse.LessOrEqualZeroError, map[Lang]string{
+		English: "the number must be greater than zero",
+		Russian: "число должно быть больше нуля",
+	}},
+	{parse.IncorrectDayTimeFormat, map[Lang]string{
+		English: "time must be written as HH:MM, HH:MM:SS or an RFC 3339 timestamp",
+		Russian: "время должно быть записано как ЧЧ:ММ, ЧЧ:ММ:СС или в формате RFC 3339",
+	}},
+	{parse.IncorrectClubWorkingTimeFormat, map[Lang]string{
+		English: "working time must be written as 'HH:MM HH:MM'",
+		Russian: "время работы должно быть записано как 'ЧЧ:ММ ЧЧ:ММ'",
+	}},
+	{parse.OpenTimeIsAfterCloseTimeError, map[Lang]string{
+		English: "the club opens after it closes",
+		Russian: "клуб открывается позже, чем закрывается",
+	}},
+	{parse.IncorrectEventFormat, map[Lang]string{
+		English: "unknown event or wrong number of fields",
+		Russian: "неизвестное событие или неверное число полей",
+	}},
+	{parse.IncorrectUnicodeClientNameFormat, map[Lang]string{
+		English: "a client name may contain only letters, digits, '_' and '-'",
+		Russian: "имя клиента может содержать только буквы, цифры, '_' и '-'",
+	}},
+	{parse.IncorrectClientNameFormat, map[Lang]string{
+		English: "a client name may contain only a..z, 0..9, '_' and '-'",
+		Russian: "имя клиента может содержать только a..z, 0..9, '_' и '-'",
+	}},
+	{parse.ClientNameTooLong, map[Lang]string{
+		English: "the client name is too long",
+		Russian: "слишком длинное имя клиента",
+	}},
+	{parse.IncorrectJSONFormat, map[Lang]string{
+		English: "not a JSON object, or a field has a wrong type or is unknown",
+		Russian: "это не JSON-объект, или у поля неверный тип, или поле неизвестно",
+	}},
+	{promo.UnknownPromotion, map[Lang]string{
+		English: "unknown promotion, should be -N%, -N, first-hour-free, 3for2 or points",
+		Russian: "неизвестная акция, ожидается -N%, -N, first-hour-free, 3for2 или points",
+	}},
+	{config.UnknownSetting, map[Lang]string{
+		English: "there is no such setting",
+		Russian: "такой настройки нет",
+	}},
+	{config.MissingSetting, map[Lang]string{
+		English: "the setting is required when the input has no header",
+		Russian: "настройка обязательна, если во входных данных нет заголовка",
+	}},
+	{config.UnknownTimeZone, map[Lang]string{
+		English: "unknown time zone",
+		Russian: "неизвестный часовой пояс",
+	}},
+	{config.IncorrectDate, map[Lang]string{
+		English: "incorrect date, should be YYYY-MM-DD",
+		Russian: "неверная дата, ожидается ГГГГ-ММ-ДД",
+	}},
+	{config.DateWithoutTimeZone, map[Lang]string{
+		English: "the date is only used together with time_zone",
+		Russian: "дата задаётся только вместе с часовым поясом",
+	}},
+	{config.IncorrectOperatorName, map[Lang]string{
+		English: "the operator name must be a word without spaces",
+		Russian: "имя оператора должно быть словом без пробелов",
+	}},
+	{config.ShiftsOutOfOrder, map[Lang]string{
+		English: "a shift starts before the previous one",
+		Russian: "смена начинается раньше предыдущей",
+	}},
+	{config.IncorrectClassName, map[Lang]string{
+		English: "the table class name must be a word without spaces",
+		Russian: "название класса столов должно быть словом без пробелов",
+	}},
+	{config.DuplicateClass, map[Lang]string{
+		English: "the table class is already defined",
+		Russian: "такой класс столов уже задан",
+	}},
+	{config.TableInTwoClasses, map[Lang]string{
+		English: "the table is already in another class",
+		Russian: "стол уже относится к другому классу",
+	}},
+	{config.TableOutOfRange, map[Lang]string{
+		English: "the table number is greater than the tables count",
+		Russian: "номер стола больше числа столов",
+	}},
+	{config.UnknownClass, map[Lang]string{
+		English: "the table class is not defined in table_classes",
+		Russian: "такого класса столов нет в table_classes",
+	}},
+	{config.TariffsOutOfOrder, map[Lang]string{
+		English: "a tariff starts before the previous one of its class",
+		Russian: "тариф начинается раньше предыдущего тарифа того же класса",
+	}},
+	{store.NonexistentTime, map[Lang]string{
+		English: "there is no such time on this day, the clocks are put forward over it",
+		Russian: "такого времени в этот день нет, часы переводятся вперёд",
+	}},
+	{store.OtherDay, map[Lang]string{
+		English: "the time is on another day than the club day",
+		Russian: "время приходится на другой день, не на рабочий день клуба",
+	}},
+	{config.IncorrectConfig, map[Lang]string{
+		English: "the config is not valid JSON or a setting has a wrong type",
+		Russian: "конфигурация не является корректным JSON или у настройки неверный тип",
+	}},
+	{strconv.ErrSyntax, map[Lang]string{
+		English: "not a number",
+		Russian: "это не число",
+	}},
+	{strconv.ErrRange, map[Lang]string{
+		English: "the number is too large",
+		Russian: "слишком большое число",
+	}},
+}
+
+var fields = map[Lang]map[string]string{
+	Russian: {
+		"tables count":   "число столов",
+		"working time":   "время работы",
+		"open time":      "время открытия",
+		"close time":     "время закрытия",
+		"hour cost":      "стоимость часа",
+		"event":          "событие",
+		"time":           "время",
+		"id":             "тип события",
+		"client":         "имя клиента",
+		"table":          "номер стола",
+		"seq":            "номер события",
+		"promotion":      "акция",
+		"config":         "конфигурация",
+		"tables":         "число столов",
+		"open_time":      "время открытия",
+		"close_time":     "время закрытия",
+		"hour_cost":      "стоимость часа",
+		"queue_capacity": "размер очереди",
+		"time_zone":      "часовой пояс",
+		"date":           "дата",
+		"charset":        "набор символов",
+		"max_length":     "максимальная длина",
+		"start":          "начало смены",
+		"operator":       "оператор",
+	},
+}
+
+// Message explains err in lang. It reports false if nothing in the error
+// chain is in the catalogue.
+func Message(lang Lang, err error) (string, bool) {
+	for _, e := range catalogue {
+		if errors.Is(err, e.err) {
+			text, ok := e.text[lang]
+			return text, ok
+		}
+	}
+	return "", false
+}
+
+// Field translates a ParseError field name, keeping it as is if there is no
+// translation.
+func Field(lang Lang, field string) string {
+	if name, ok := fields[lang][field]; ok {
+		return name
+	}
+	return field
+}
+
+type localized struct {
+	text string
+	err  error
+}
+
+func (e *localized) Error() string { return e.text }
+func (e *localized) Unwrap() error { return e.err }
+
+// Localize returns an error that reads in lang and still matches the same
+// errors with errors.Is. A ParseError keeps its position.
+func Localize(lang Lang, err error) error {
+	text, ok := Message(lang, err)
+	if !ok {
+		return err
+	}
+
+	var perr *parse.ParseError
+	if !errors.As(err, &perr) {
+		return &localized{text: text, err: err}
+	}
+	located := *perr
+	located.Field = Field(lang, perr.Field)
+	located.Err = &localized{text: text, err: perr.Err}
+	return &located
+}
+
+// EventFormatter renders generated events for staff: error events get an
+// explanation in lang after the protocol name.
+func EventFormatter(lang Lang) func(e event.Event) string {
+	return func(e event.Event) string {
+		if errEvent, ok := e.(*event.ErrorEvent); ok {
+			if text, ok := Message(lang, errEvent.Err()); ok {
+				return fmt.Sprintf("%s (%s)", errEvent, text)
+			}
+		}
+		return fmt.Sprint(e)
+	}
+}
@@ -0,0 +1,100 @@
+package i18n_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/config"
+	"github.com/GerogeGol/yadro-test-problem/domain/i18n"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+var langs = []i18n.Lang{i18n.English, i18n.Russian}
+
+func TestMessage(t *testing.T) {
+	t.Run("every domain error is translated", func(t *testing.T) {
+		for _, e := range service.Catalogue {
+			for _, lang := range langs {
+				_, ok := i18n.Message(lang, e)
+				if !ok {
+					t.Fatalf("no %s message for %s", lang, e.Code)
+				}
+			}
+		}
+	})
+
+	t.Run("every input and config error is translated", func(t *testing.T) {
+		errs := []error{
+			parse.LessOrEqualZeroError,
+			parse.IncorrectDayTimeFormat,
+			parse.IncorrectClubWorkingTimeFormat,
+			parse.OpenTimeIsAfterCloseTimeError,
+			parse.IncorrectEventFormat,
+			parse.IncorrectClientNameFormat,
+			parse.IncorrectUnicodeClientNameFormat,
+			parse.IncorrectJSONFormat,
+			parse.ClientNameTooLong,
+			promo.UnknownPromotion,
+			scan.EventTimeIsBeforePrevious,
+			config.IncorrectConfig,
+			config.UnknownSetting,
+			config.MissingSetting,
+			config.UnknownTimeZone,
+			config.IncorrectDate,
+			config.DateWithoutTimeZone,
+			config.IncorrectOperatorName,
+			config.ShiftsOutOfOrder,
+			config.IncorrectClassName,
+			config.DuplicateClass,
+			config.TableInTwoClasses,
+			config.TableOutOfRange,
+			config.UnknownClass,
+			config.TariffsOutOfOrder,
+			store.NonexistentTime,
+			store.OtherDay,
+		}
+		for _, e := range errs {
+			for _, lang := range langs {
+				_, ok := i18n.Message(lang, e)
+				if !ok {
+					t.Fatalf("no %s message for %q", lang, e)
+				}
+			}
+		}
+	})
+
+	t.Run("message is found through the error chain", func(t *testing.T) {
+		_, err := parse.TablesCount("x")
+		msg, ok := i18n.Message(i18n.Russian, err)
+		test.AssertTrue(t, ok)
+		test.AssertEqual(t, msg, "это не число")
+	})
+}
+
+func TestLocalize(t *testing.T) {
+	_, err := parse.InputEvent("09:00 1 client!")
+	localized := i18n.Localize(i18n.Russian, err)
+
+	test.AssertError(t, localized, parse.IncorrectClientNameFormat)
+
+	var perr *parse.ParseError
+	test.AssertTrue(t, errors.As(localized, &perr))
+	test.AssertEqual(t, perr.Field, "имя клиента")
+	test.AssertEqual(t, perr.Col, 9)
+}
+
+func TestEventFormatter(t *testing.T) {
+	format := i18n.EventFormatter(i18n.English)
+
+	errEvent := event.NewErrorEvent(test.DummyDayTime, service.PlaceIsBusy)
+	test.AssertEqual(t, format(errEvent), "00:00 13 PlaceIsBusy (the table is taken)")
+
+	leaveEvent := event.NewOutLeaveEvent(test.DummyDayTime, test.DummyClient)
+	test.AssertEqual(t, format(leaveEvent), "00:00 11 client")
+}
@@ -0,0 +1,12 @@
+package logging
+
+import (
+	"io"
+	"log/slog"
+)
+
+// Discard returns a logger that drops every record, for the packages that
+// log only when given a logger.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+)
+
+// SessionBuckets are the upper bounds of the session duration histogram.
+var SessionBuckets = []time.Duration{
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	4 * time.Hour,
+	8 * time.Hour,
+}
+
+// Metrics follows the domain events of a club, attach Handle to its bus,
+// and serves what it has recorded over HTTP in the Prometheus text format.
+// The zero value is not usable, use New.
+type Metrics struct {
+	mu           sync.Mutex
+	events       map[int]float64
+	errors       map[string]float64
+	revenue      map[int]float64
+	reversed     map[int]float64
+	busyTables   int
+	queueLength  int
+	sessions     []uint64
+	sessionSum   time.Duration
+	sessionCount uint64
+	// sessionsReversed counts the sessions corrections took back
+	sessionsReversed uint64
+}
+
+func New() *Metrics {
+	return &Metrics{
+		events:   map[int]float64{},
+		errors:   map[string]float64{},
+		revenue:  map[int]float64{},
+		reversed: map[int]float64{},
+		sessions: make([]uint64, len(SessionBuckets)),
+	}
+}
+
+// Handle records a domain event of the club.
+func (m *Metrics) Handle(e service.DomainEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch e := e.(type) {
+	case service.EventServed:
+		m.events[e.ID]++
+		if e.Err != nil {
+			m.errors[service.ErrorCode(e.Err)]++
+		}
+		m.busyTables, m.queueLength = e.BusyTables, e.QueueLength
+	case service.SessionBilled:
+		m.bill(e)
+	case service.EventCorrected:
+		for _, b := range e.Unbilled {
+			m.reverse(b)
+		}
+		for _, b := range e.Billed {
+			m.bill(b)
+		}
+	case service.ClubClosed:
+		m.busyTables, m.queueLength = 0, 0
+	}
+}
+
+// bill adds a session to the revenue and the histogram.
+func (m *Metrics) bill(e service.SessionBilled) {
+	m.revenue[e.Table] += e.Payment
+	for i, bound := range SessionBuckets {
+		if e.Played <= bound {
+			m.sessions[i]++
+		}
+	}
+	m.sessionSum += e.Played
+	m.sessionCount++
+}
+
+// reverse records a session added by bill and taken back by a correction.
+// The counters only grow, so the net revenue of a table is its revenue minus
+// its reversed revenue.
+func (m *Metrics) reverse(e service.SessionBilled) {
+	m.reversed[e.Table] += e.Payment
+	m.sessionsReversed++
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p := &printer{w: w}
+	p.family("club_events_total", "counter", "Input events served, by event ID.")
+	for _, id := range sortedKeys(m.events) {
+		p.sample("club_events_total", label("id", strconv.Itoa(id)), m.events[id])
+	}
+	p.family("club_errors_total", "counter", "Events the club answered with an error, by error code.")
+	for _, code := range sortedKeys(m.errors) {
+		p.sample("club_errors_total", label("error", code), m.errors[code])
+	}
+	p.family("club_busy_tables", "gauge", "Tables taken by clients.")
+	p.sample("club_busy_tables", "", float64(m.busyTables))
+	p.family("club_queue_length", "gauge", "Clients waiting for a table.")
+	p.sample("club_queue_length", "", float64(m.queueLength))
+	p.family("club_revenue_total", "counter", "Money paid for the tables, by table number.")
+	for _, table := range sortedKeys(m.revenue) {
+		p.sample("club_revenue_total", label("table", strconv.Itoa(table)), m.revenue[table])
+	}
+	p.family("club_revenue_reversed_total", "counter", "Money of sessions taken back by corrections, by table number.")
+	for _, table := range sortedKeys(m.reversed) {
+		p.sample("club_revenue_reversed_total", label("table", strconv.Itoa(table)), m.reversed[table])
+	}
+	p.family("club_sessions_reversed_total", "counter", "Sessions taken back by corrections.")
+	p.sample("club_sessions_reversed_total", "", float64(m.sessionsReversed))
+
+	p.family("club_session_duration_seconds", "histogram", "Time clients spent at a table.")
+	for i, bound := range SessionBuckets {
+		p.sample("club_session_duration_seconds_bucket", label("le", formatFloat(bound.Seconds())), float64(m.sessions[i]))
+	}
+	p.sample("club_session_duration_seconds_bucket", label("le", "+Inf"), float64(m.sessionCount))
+	p.sample("club_session_duration_seconds_sum", "", m.sessionSum.Seconds())
+	p.sample("club_session_duration_seconds_count", "", float64(m.sessionCount))
+	return p.n, p.err
+}
+
+type printer struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (p *printer) printf(format string, args ...any) {
+	if p.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(p.w, format, args...)
+	p.n += int64(n)
+	p.err = err
+}
+
+func (p *printer) family(name, kind, help string) {
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (p *printer) sample(name, labels string, value float64) {
+	p.printf("%s%s %s\n", name, labels, formatFloat(value))
+}
+
+func label(name, value string) string {
+	return fmt.Sprintf("{%s=%s}", name, strconv.Quote(value))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[K int | string](m map[K]float64) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
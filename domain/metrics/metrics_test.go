@@ -0,0 +1,130 @@
+package metrics_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/metrics"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	test.AssertNoError(t, err)
+	defer resp.Body.Close()
+	test.AssertEqual(t, resp.Header.Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+
+	body, err := io.ReadAll(resp.Body)
+	test.AssertNoError(t, err)
+	return string(body)
+}
+
+func assertSamples(t *testing.T, body string, samples ...string) {
+	t.Helper()
+	lines := strings.Split(body, "\n")
+	for _, sample := range samples {
+		found := false
+		for _, line := range lines {
+			found = found || line == sample
+		}
+		if !found {
+			t.Errorf("no sample %q in:\n%s", sample, body)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("basic input", func(t *testing.T) {
+		file, err := os.Open("../../tests/basic.txt")
+		test.AssertNoError(t, err)
+		defer file.Close()
+
+		m := metrics.New()
+		p := &scan.Processor{DomainEvents: bus.New[service.DomainEvent]()}
+		p.DomainEvents.Handle(m.Handle)
+		test.AssertNoError(t, p.Process(file, io.Discard, scan.DiscardOnError))
+
+		assertSamples(t, scrape(t, m),
+			"# TYPE club_events_total counter",
+			`club_events_total{id="1"} 5`,
+			`club_events_total{id="2"} 4`,
+			`club_events_total{id="3"} 2`,
+			`club_events_total{id="4"} 3`,
+			`club_errors_total{error="CLUB_CLOSED"} 1`,
+			`club_errors_total{error="FREE_TABLE_AVAILABLE"} 1`,
+			`club_errors_total{error="TABLE_BUSY"} 1`,
+			"club_busy_tables 0",
+			"club_queue_length 0",
+			`club_revenue_total{table="1"} 70`,
+			`club_revenue_total{table="2"} 30`,
+			`club_revenue_total{table="3"} 90`,
+			"# TYPE club_session_duration_seconds histogram",
+			`club_session_duration_seconds_bucket{le="7200"} 0`,
+			`club_session_duration_seconds_bucket{le="14400"} 3`,
+			`club_session_duration_seconds_bucket{le="28800"} 3`,
+			`club_session_duration_seconds_bucket{le="+Inf"} 4`,
+			"club_session_duration_seconds_count 4",
+		)
+	})
+
+	t.Run("corrections count the revenue taken back", func(t *testing.T) {
+		input := `2
+09:00 19:00
+10
+09:00 1 a
+09:00 2 a 1
+10:00 1 b
+10:00 2 b 2
+11:00 4 a
+12:00 4 b
+12:30 5 5
+`
+		m := metrics.New()
+		p := &scan.Processor{DomainEvents: bus.New[service.DomainEvent](), Corrections: true}
+		p.DomainEvents.Handle(m.Handle)
+		out := &strings.Builder{}
+		test.AssertNoError(t, p.Process(strings.NewReader(input), out, scan.DiscardOnError))
+
+		// a left after the close, the table made 100: the 20 billed at 11:00
+		// are reversed and the counters keep growing
+		test.AssertTrue(t, strings.Contains(out.String(), "\n1 100 10:00\n2 20 02:00\n"))
+		assertSamples(t, scrape(t, m),
+			`club_revenue_total{table="1"} 120`,
+			`club_revenue_total{table="2"} 20`,
+			`club_revenue_reversed_total{table="1"} 20`,
+			`club_sessions_reversed_total 1`,
+			`club_session_duration_seconds_count 3`,
+		)
+	})
+
+	t.Run("occupancy during the day", func(t *testing.T) {
+		m := metrics.New()
+		m.Handle(service.EventServed{ID: event.WaitEventId, BusyTables: 2, QueueLength: 1})
+		assertSamples(t, scrape(t, m), "club_busy_tables 2", "club_queue_length 1")
+
+		m.Handle(service.ClubClosed{})
+		assertSamples(t, scrape(t, m), "club_busy_tables 0", "club_queue_length 0")
+	})
+
+	t.Run("errors that are not domain errors", func(t *testing.T) {
+		m := metrics.New()
+		m.Handle(service.EventServed{ID: event.ArrivalEventId, Err: io.ErrUnexpectedEOF})
+		m.Handle(service.EventServed{ID: event.ArrivalEventId, Err: service.ClientUnknown})
+		assertSamples(t, scrape(t, m),
+			`club_events_total{id="1"} 2`,
+			`club_errors_total{error="INTERNAL"} 1`,
+			`club_errors_total{error="CLIENT_UNKNOWN"} 1`,
+		)
+	})
+}
@@ -0,0 +1,73 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError describes an incorrect input line: where the offending token is,
+// which field it was parsed as and why it was rejected. Parse functions leave
+// Line zero, the scanner fills it in.
+type ParseError struct {
+	Line  int
+	Col   int
+	Raw   string
+	Field string
+	Token string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s %q: %v", e.Line, e.Field, e.Token, e.Err)
+	}
+	return fmt.Sprintf("%s %q: %v", e.Field, e.Token, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Diagnostic formats the error the way compilers do: a file:line:col header,
+// the raw line and a caret under the offending token.
+func (e *ParseError) Diagnostic(file string) string {
+	col := max(e.Col, 1)
+	// the caret is aligned by characters, Col counts bytes like compilers do
+	indent := utf8.RuneCountInString(e.Raw[:min(col-1, len(e.Raw))])
+	width := max(utf8.RuneCountInString(e.Token), 1)
+	return fmt.Sprintf("%s:%d:%d: %s %q: %v\n%s\n%s^%s\n",
+		file, e.Line, col, e.Field, e.Token, e.Err,
+		e.Raw,
+		strings.Repeat(" ", indent), strings.Repeat("~", width-1),
+	)
+}
+
+// NewParseError returns a ParseError for the i-th space separated token of
+// raw, or for the whole line if i is negative.
+func NewParseError(raw, field string, i int, err error) *ParseError {
+	e := &ParseError{Raw: raw, Field: field, Token: raw, Col: 1, Err: err}
+	if i < 0 {
+		return e
+	}
+
+	parts := strings.Split(raw, " ")
+	if i >= len(parts) {
+		e.Token, e.Col = "", len(raw)+1
+		return e
+	}
+	for _, part := range parts[:i] {
+		e.Col += len(part) + 1
+	}
+	e.Token = parts[i]
+	return e
+}
+
+// reposition moves an error found in a token of raw to the token's place in
+// raw, keeping the cause.
+func reposition(err error, raw, field string, i int) error {
+	if perr, ok := err.(*ParseError); ok {
+		err = perr.Err
+	}
+	return NewParseError(raw, field, i, err)
+}
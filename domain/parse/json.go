@@ -0,0 +1,291 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+var IncorrectJSONFormat = fmt.Errorf("incorrect JSON line")
+
+// IsJSONLine reports whether s looks like a JSON Lines record rather than a
+// space separated one.
+func IsJSONLine(s string) bool {
+	return strings.HasPrefix(strings.TrimLeft(s, " \t"), "{")
+}
+
+var UnknownTimeZone = fmt.Errorf("unknown time zone")
+
+type jsonHeader struct {
+	Tables        *int     `json:"tables"`
+	OpenTime      *string  `json:"open_time"`
+	CloseTime     *string  `json:"close_time"`
+	HourCost      *float64 `json:"hour_cost"`
+	QueueCapacity *int     `json:"queue_capacity"`
+	TimeZone      *string  `json:"time_zone"`
+}
+
+// ClubHeader is the first line of JSON Lines input: the settings of the
+// three text header lines and the club settings a config may set too.
+type ClubHeader struct {
+	TablesCount int
+	OpenTime    store.DayTime
+	CloseTime   store.DayTime
+	HourCost    float64
+	// QueueCapacity is zero and Location is nil when the line doesn't set
+	// them.
+	QueueCapacity int
+	Location      *time.Location
+}
+
+// JSONHeader parses the first line of JSON Lines input:
+//
+//	{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10}
+//
+// The queue capacity and the time zone are optional, as in the club config.
+// With a time zone the club may close after midnight:
+//
+//	{"tables": 3, "open_time": "22:00", "close_time": "06:00", "hour_cost": 10, "queue_capacity": 5, "time_zone": "Europe/Moscow"}
+func JSONHeader(s string) (h ClubHeader, err error) {
+	var j jsonHeader
+	if err = decodeJSONLine(s, &j); err != nil {
+		return
+	}
+
+	switch {
+	case j.Tables == nil:
+		err = NewJSONError(s, "tables", IncorrectJSONFormat)
+	case j.OpenTime == nil:
+		err = NewJSONError(s, "open_time", IncorrectJSONFormat)
+	case j.CloseTime == nil:
+		err = NewJSONError(s, "close_time", IncorrectJSONFormat)
+	case j.HourCost == nil:
+		err = NewJSONError(s, "hour_cost", IncorrectJSONFormat)
+	case *j.Tables <= 0:
+		err = NewJSONError(s, "tables", LessOrEqualZeroError)
+	case *j.HourCost <= 0:
+		err = NewJSONError(s, "hour_cost", LessOrEqualZeroError)
+	case j.QueueCapacity != nil && *j.QueueCapacity < 0:
+		err = NewJSONError(s, "queue_capacity", fmt.Errorf("value could not be negative: %w", IncorrectJSONFormat))
+	}
+	if err != nil {
+		return
+	}
+	h.TablesCount, h.HourCost = *j.Tables, *j.HourCost
+	if j.QueueCapacity != nil {
+		h.QueueCapacity = *j.QueueCapacity
+	}
+	if j.TimeZone != nil {
+		if h.Location, err = time.LoadLocation(*j.TimeZone); err != nil || *j.TimeZone == "" {
+			err = NewJSONError(s, "time_zone", UnknownTimeZone)
+			return
+		}
+	}
+
+	if h.OpenTime, err = DayTime(*j.OpenTime); err != nil {
+		err = repositionJSON(err, s, "open_time")
+		return
+	}
+	if h.CloseTime, err = DayTime(*j.CloseTime); err != nil {
+		err = repositionJSON(err, s, "close_time")
+		return
+	}
+	if h.Location == nil && h.OpenTime.Compare(h.CloseTime.Time) == 1 {
+		err = NewJSONError(s, "open_time", OpenTimeIsAfterCloseTimeError)
+		return
+	}
+	return h, nil
+}
+
+type jsonEvent struct {
+	Time      *string `json:"time"`
+	ID        *int    `json:"id"`
+	Seq       *int    `json:"seq"`
+	Client    *string `json:"client"`
+	Table     *int    `json:"table"`
+	Promotion *string `json:"promotion"`
+}
+
+// JSONInputEvent parses a JSON Lines event with the same rules as
+// InputEvent:
+//
+//	{"time": "09:41", "id": 2, "client": "client1", "table": 1}
+//
+// Promotions are written the way they are in the text input:
+//
+//	{"time": "09:45", "id": 7, "client": "client1", "promotion": "-10%"}
+//
+// Corrections have the sequence number of the event they correct:
+//
+//	{"time": "09:45", "id": 6, "seq": 2, "client": "client1", "table": 3}
+func JSONInputEvent(s string) (event.InputEvent, error) {
+	return Parser{}.JSONInputEvent(s)
+}
+
+func (p Parser) JSONInputEvent(s string) (event.InputEvent, error) {
+	var e jsonEvent
+	if err := decodeJSONLine(s, &e); err != nil {
+		return event.EmptyInputEvent, err
+	}
+
+	switch {
+	case e.Time == nil:
+		return event.EmptyInputEvent, NewJSONError(s, "time", IncorrectEventFormat)
+	case e.ID == nil:
+		return event.EmptyInputEvent, NewJSONError(s, "id", IncorrectEventFormat)
+	}
+	if *e.ID == event.VoidEventId || *e.ID == event.AmendEventId {
+		return p.jsonCorrection(s, e)
+	}
+	switch {
+	case e.Seq != nil:
+		return event.EmptyInputEvent, NewJSONError(s, "seq", IncorrectEventFormat)
+	case e.Client == nil:
+		return event.EmptyInputEvent, NewJSONError(s, "client", IncorrectEventFormat)
+	}
+
+	t, err := p.DayTime(*e.Time)
+	if err != nil {
+		return event.EmptyInputEvent, repositionJSON(err, s, "time")
+	}
+	client, err := p.Names.ClientName(*e.Client)
+	if err != nil {
+		return event.EmptyInputEvent, NewJSONError(s, "client", err)
+	}
+
+	if *e.ID != event.SitDownEventId && e.Table != nil {
+		return event.EmptyInputEvent, NewJSONError(s, "table", IncorrectEventFormat)
+	}
+	if *e.ID != event.PromotionEventId && e.Promotion != nil {
+		return event.EmptyInputEvent, NewJSONError(s, "promotion", IncorrectEventFormat)
+	}
+
+	switch *e.ID {
+	case event.ArrivalEventId:
+		return event.NewArrivalEvent(t, client), nil
+	case event.SitDownEventId:
+		if e.Table == nil {
+			return event.EmptyInputEvent, NewJSONError(s, "table", IncorrectEventFormat)
+		}
+		if *e.Table <= 0 {
+			return event.EmptyInputEvent, NewJSONError(s, "table", LessOrEqualZeroError)
+		}
+		return event.NewSitDownEvent(t, client, *e.Table), nil
+	case event.WaitEventId:
+		return event.NewWaitEvent(t, client), nil
+	case event.LeaveEventId:
+		return event.NewLeaveEvent(t, client), nil
+	case event.PromotionEventId:
+		if e.Promotion == nil {
+			return event.EmptyInputEvent, NewJSONError(s, "promotion", IncorrectEventFormat)
+		}
+		if _, err := promo.Parse(*e.Promotion); err != nil {
+			return event.EmptyInputEvent, NewJSONError(s, "promotion", errors.Unwrap(err))
+		}
+		return event.NewPromotionEvent(t, client, *e.Promotion), nil
+	}
+	return event.EmptyInputEvent, NewJSONError(s, "id", IncorrectEventFormat)
+}
+
+func (p Parser) jsonCorrection(s string, e jsonEvent) (event.InputEvent, error) {
+	t, err := p.DayTime(*e.Time)
+	if err != nil {
+		return event.EmptyInputEvent, repositionJSON(err, s, "time")
+	}
+	switch {
+	case e.Promotion != nil:
+		return event.EmptyInputEvent, NewJSONError(s, "promotion", IncorrectEventFormat)
+	case e.Seq == nil:
+		return event.EmptyInputEvent, NewJSONError(s, "seq", IncorrectEventFormat)
+	case *e.Seq <= 0:
+		return event.EmptyInputEvent, NewJSONError(s, "seq", LessOrEqualZeroError)
+	}
+
+	if *e.ID == event.VoidEventId {
+		if e.Client != nil {
+			return event.EmptyInputEvent, NewJSONError(s, "client", IncorrectEventFormat)
+		}
+		if e.Table != nil {
+			return event.EmptyInputEvent, NewJSONError(s, "table", IncorrectEventFormat)
+		}
+		return event.NewVoidEvent(t, *e.Seq), nil
+	}
+
+	if e.Client == nil {
+		return event.EmptyInputEvent, NewJSONError(s, "client", IncorrectEventFormat)
+	}
+	client, err := p.Names.ClientName(*e.Client)
+	if err != nil {
+		return event.EmptyInputEvent, NewJSONError(s, "client", err)
+	}
+	var table int
+	if e.Table != nil {
+		if *e.Table <= 0 {
+			return event.EmptyInputEvent, NewJSONError(s, "table", LessOrEqualZeroError)
+		}
+		table = *e.Table
+	}
+	return event.NewAmendEvent(t, *e.Seq, client, table), nil
+}
+
+func decodeJSONLine(s string, v any) error {
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return NewJSONError(s, typeErr.Field, IncorrectJSONFormat)
+		}
+		return NewJSONError(s, "event", IncorrectJSONFormat)
+	}
+	if decoder.More() {
+		return NewJSONError(s, "event", IncorrectJSONFormat)
+	}
+	return nil
+}
+
+// NewJSONError returns a ParseError for the value of key in the JSON object
+// raw, or for the whole line if there is no such key.
+func NewJSONError(raw, key string, err error) *ParseError {
+	e := &ParseError{Raw: raw, Field: key, Token: raw, Col: 1, Err: err}
+
+	at := strings.Index(raw, strconv.Quote(key))
+	if at < 0 {
+		return e
+	}
+	colon := strings.IndexByte(raw[at:], ':')
+	if colon < 0 {
+		return e
+	}
+	start := at + colon + 1
+	for start < len(raw) && raw[start] == ' ' {
+		start++
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(raw[start:]))
+	var value json.RawMessage
+	if decoder.Decode(&value) != nil {
+		return e
+	}
+	token := string(bytes.TrimSpace(value))
+	if unquoted, err := strconv.Unquote(token); err == nil {
+		token = unquoted
+		start++
+	}
+	e.Token, e.Col = token, start+1
+	return e
+}
+
+func repositionJSON(err error, raw, key string) error {
+	if perr, ok := err.(*ParseError); ok {
+		err = perr.Err
+	}
+	return NewJSONError(raw, key, err)
+}
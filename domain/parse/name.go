@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var ClientNameTooLong = fmt.Errorf("client name is too long")
+
+// IncorrectUnicodeClientNameFormat is IncorrectClientNameFormat under the
+// unicode policy.
+var IncorrectUnicodeClientNameFormat error = &nameError{"incorrect client name format. should contain only letters, digits, '_' and '-'"}
+
+// nameError is an incorrect name with a message for its policy.
+type nameError struct {
+	msg string
+}
+
+func (e *nameError) Error() string {
+	return e.msg
+}
+
+func (e *nameError) Unwrap() error {
+	return IncorrectClientNameFormat
+}
+
+// NamePolicy decides which client names are accepted. The zero value is the
+// policy of the task: a..z, 0..9, '_' and '-' only.
+type NamePolicy struct {
+	// Unicode accepts letters of any script and case and decimal digits, so
+	// that staff can type Cyrillic names. The names are put in NFC, so that
+	// an accented letter typed as one character or as a letter and a mark
+	// is the same client.
+	Unicode bool
+	// MaxLength limits the name length in characters, zero means no limit.
+	MaxLength int
+	// FoldCase accepts upper case letters and turns names into lower case,
+	// so that "Anna" and "anna" are the same client.
+	FoldCase bool
+}
+
+// ClientName validates s and returns the name that identifies the client.
+func (p NamePolicy) ClientName(s string) (string, error) {
+	if s == "" || !utf8.ValidString(s) {
+		return "", p.formatError()
+	}
+	if p.Unicode {
+		s = norm.NFC.String(s)
+	}
+	if p.FoldCase {
+		s = strings.ToLower(s)
+	}
+	for _, r := range s {
+		if !p.allowed(r) {
+			return "", p.formatError()
+		}
+	}
+	if p.MaxLength > 0 && utf8.RuneCountInString(s) > p.MaxLength {
+		return "", ClientNameTooLong
+	}
+	return s, nil
+}
+
+func (p NamePolicy) allowed(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+		return true
+	case p.Unicode:
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return false
+}
+
+func (p NamePolicy) formatError() error {
+	if p.Unicode {
+		return IncorrectUnicodeClientNameFormat
+	}
+	return IncorrectClientNameFormat
+}
@@ -1,26 +1,37 @@
 package parse
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"math"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
 )
 
-var LessOrEqualZeroError = fmt.Errorf("tables count could not be less or equal 0")
-var IncorrectDayTimeFormat = fmt.Errorf("incorrect DayTime format. Should be 'XX:XX'")
+var LessOrEqualZeroError = fmt.Errorf("value could not be less or equal 0")
+var IncorrectDayTimeFormat = fmt.Errorf("incorrect DayTime format. Should be 'XX:XX', 'XX:XX:XX' or an RFC 3339 timestamp")
 var IncorrectClubWorkingTimeFormat = fmt.Errorf("incorrect club working time format. Should be 'XX:XX XX:XX'")
 var OpenTimeIsAfterCloseTimeError = fmt.Errorf("open time could not be after close time")
 var IncorrectEventFormat = fmt.Errorf("incorrect event format")
 var IncorrectClientNameFormat = fmt.Errorf("incorrect client name format. should contain only a..z letters, 0..9 numbers, '_' and '-'")
 
+// Parser parses input events, checking client names with its policy and
+// putting event times on the club day of its clock. The zero value follows
+// the task.
+type Parser struct {
+	Names NamePolicy
+	Clock store.Clock
+}
+
 func TablesCount(s string) (int, error) {
 	tablesCount, err := positiveNumber(s)
 	if err != nil {
-		return 0, fmt.Errorf("parse.TablesCount: %w", err)
+		return 0, NewParseError(s, "tables count", -1, err)
 	}
 	return tablesCount, nil
 }
@@ -28,174 +39,301 @@ func TablesCount(s string) (int, error) {
 func HourCost(s string) (float64, error) {
 	hourCost, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return 0, fmt.Errorf("parse.HourCost: %w", err)
+		return 0, NewParseError(s, "hour cost", -1, err)
+	}
+	if math.IsNaN(hourCost) || math.IsInf(hourCost, 0) {
+		return 0, NewParseError(s, "hour cost", -1, strconv.ErrSyntax)
 	}
 	if hourCost <= 0 {
-		return 0, fmt.Errorf("parse.HourCost: %w", LessOrEqualZeroError)
+		return 0, NewParseError(s, "hour cost", -1, LessOrEqualZeroError)
 	}
 	return hourCost, nil
 }
 
+// DayTime parses a time of day as HH:MM, HH:MM:SS or an RFC 3339 timestamp.
+// Without a time zone the club keeps its times in UTC, so a timestamp is
+// converted to UTC and its date is not checked; Parser.DayTime checks it
+// against the club day. Fractions of a second are dropped.
 func DayTime(s string) (time store.DayTime, err error) {
+	if strings.ContainsAny(s, "Tt") {
+		return timestamp(s)
+	}
+
 	parts := strings.Split(s, ":")
-	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 {
-		err = fmt.Errorf("parse.DayTime: %w", IncorrectDayTimeFormat)
+	if len(parts) != 2 && len(parts) != 3 {
+		err = NewParseError(s, "time", -1, IncorrectDayTimeFormat)
 		return
 	}
+	for _, part := range parts {
+		if len(part) != 2 || !isDigits(part) {
+			err = NewParseError(s, "time", -1, IncorrectDayTimeFormat)
+			return
+		}
+	}
 
 	hours, err := strconv.Atoi(parts[0])
 	if err != nil {
-		err = fmt.Errorf("parse.DayTime: %w", err)
+		err = NewParseError(s, "time", -1, err)
 		return
 	}
 
 	if hours < 0 || hours >= 24 {
-		err = fmt.Errorf("parse.DayTime: %w", IncorrectDayTimeFormat)
+		err = NewParseError(s, "time", -1, IncorrectDayTimeFormat)
 		return
 	}
 
 	minutes, err := strconv.Atoi(parts[1])
 	if err != nil {
-		err = fmt.Errorf("parse.DayTime: %w", err)
+		err = NewParseError(s, "time", -1, err)
+		return
+	}
+
+	if minutes < 0 || minutes >= 60 {
+		err = NewParseError(s, "time", -1, IncorrectDayTimeFormat)
 		return
 	}
 
-	if minutes < 0 || minutes > 60 {
-		err = fmt.Errorf("parse.DayTime: %w", IncorrectDayTimeFormat)
+	if len(parts) == 2 {
+		return store.NewDayTime(hours, minutes), nil
+	}
+
+	seconds, err := strconv.Atoi(parts[2])
+	if err != nil {
+		err = NewParseError(s, "time", -1, err)
 		return
 	}
-	return store.NewDayTime(hours, minutes), nil
+
+	if seconds < 0 || seconds >= 60 {
+		err = NewParseError(s, "time", -1, IncorrectDayTimeFormat)
+		return
+	}
+	return store.NewDayTimeSeconds(hours, minutes, seconds), nil
+}
+
+func timestamp(s string) (store.DayTime, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return store.DayTime{}, NewParseError(s, "time", -1, IncorrectDayTimeFormat)
+	}
+	t = t.UTC()
+	return store.NewDayTimeSeconds(t.Hour(), t.Minute(), t.Second()), nil
+}
+
+// DayTime parses an event time on the club day. A timestamp is the moment
+// it names, seen in the club time zone, so the offset tells apart the two
+// times of day repeated when the clocks are put back. A timestamp outside
+// the club day is an error.
+func (p Parser) DayTime(s string) (store.DayTime, error) {
+	if p.Clock.IsZero() {
+		return DayTime(s)
+	}
+	if strings.ContainsAny(s, "Tt") {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return store.DayTime{}, NewParseError(s, "time", -1, IncorrectDayTimeFormat)
+		}
+		d, err := p.Clock.On(t.Truncate(time.Second))
+		if err != nil {
+			return d, NewParseError(s, "time", -1, err)
+		}
+		return d, nil
+	}
+
+	d, err := DayTime(s)
+	if err != nil {
+		return d, err
+	}
+	if d, err = p.Clock.At(d); err != nil {
+		return d, NewParseError(s, "time", -1, err)
+	}
+	return d, nil
 }
 
 func ClubWorkingTime(s string) (openTime store.DayTime, closeTime store.DayTime, err error) {
 	parts := strings.Split(s, " ")
 	if len(parts) != 2 {
-		err = fmt.Errorf("parse.ClubWorkingTime: %w", IncorrectClubWorkingTimeFormat)
+		err = NewParseError(s, "working time", -1, IncorrectClubWorkingTimeFormat)
 		return
 	}
 
 	openTime, err = DayTime(parts[0])
 	if err != nil {
-		err = fmt.Errorf("parse.ClubWorkingTime: %w", err)
+		err = reposition(err, s, "open time", 0)
 		return
 
 	}
 	closeTime, err = DayTime(parts[1])
 	if err != nil {
-		err = fmt.Errorf("parse.ClubWorkingTime: %w", err)
+		err = reposition(err, s, "close time", 1)
 		return
 	}
 
 	if openTime.Compare(closeTime.Time) == 1 {
-		err = fmt.Errorf("parse.ClubWorkingTime: %w", OpenTimeIsAfterCloseTimeError)
+		err = NewParseError(s, "working time", -1, OpenTimeIsAfterCloseTimeError)
 		return
 	}
 
 	return
 }
 
-func ArriveEvent(s string) (e *event.ArriveEvent, err error) {
+func ArriveEvent(s string) (*event.ArriveEvent, error) {
+	return Parser{}.ArriveEvent(s)
+}
+
+func (p Parser) ArriveEvent(s string) (e *event.ArriveEvent, err error) {
 	parts := strings.Split(s, " ")
 	if len(parts) != 3 {
-		err = fmt.Errorf("parse.ArriveEvent: %w", IncorrectEventFormat)
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
 		return
 	}
 
-	t, id, client, err := inputEvent(s)
+	t, id, client, err := p.inputEvent(s)
 	if err != nil {
 		return
 	}
 
 	if id != event.ArrivalEventId {
-		err = fmt.Errorf("parse.ArriveEvent: cant parse id %w", IncorrectEventFormat)
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
 		return
 	}
 
 	return event.NewArrivalEvent(t, client), nil
 }
 
-func SitDownEvent(s string) (e *event.SitDownEvent, err error) {
+func SitDownEvent(s string) (*event.SitDownEvent, error) {
+	return Parser{}.SitDownEvent(s)
+}
+
+func (p Parser) SitDownEvent(s string) (e *event.SitDownEvent, err error) {
 	parts := strings.Split(s, " ")
 	if len(parts) != 4 {
-		err = fmt.Errorf("parse.SitDownEvent: %w", IncorrectEventFormat)
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
 		return
 	}
-	t, id, client, err := inputEvent(s)
+	t, id, client, err := p.inputEvent(s)
 	if err != nil {
-		err = fmt.Errorf("parse.SitDownEvent: %w", err)
 		return
 	}
 	if id != event.SitDownEventId {
-		err = fmt.Errorf("parse.SitDownEvent: incorrect id %w", IncorrectEventFormat)
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
 		return
 	}
 
 	tableNumber, err := positiveNumber(parts[3])
 	if err != nil {
-		err = fmt.Errorf("parse.SitDownEvent: cant parse table number %w", err)
+		err = NewParseError(s, "table", 3, err)
 		return
 	}
 
 	return event.NewSitDownEvent(t, client, tableNumber), nil
 }
 
-func WaitEvent(s string) (e *event.WaitEvent, err error) {
+func WaitEvent(s string) (*event.WaitEvent, error) {
+	return Parser{}.WaitEvent(s)
+}
+
+func (p Parser) WaitEvent(s string) (e *event.WaitEvent, err error) {
 	parts := strings.Split(s, " ")
 	if len(parts) != 3 {
-		err = fmt.Errorf("parse.WaitEvent: %w", IncorrectEventFormat)
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
 		return
 	}
-	t, id, client, err := inputEvent(s)
+	t, id, client, err := p.inputEvent(s)
 	if err != nil {
-		err = fmt.Errorf("parse.WaitEvent: %w", err)
 		return
 	}
 	if id != event.WaitEventId {
-		err = fmt.Errorf("parse.WaitEvent: incorrect id %w", IncorrectEventFormat)
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
 		return
 	}
 
 	return event.NewWaitEvent(t, client), nil
 }
 
-func LeaveEvent(s string) (e *event.LeaveEvent, err error) {
+func LeaveEvent(s string) (*event.LeaveEvent, error) {
+	return Parser{}.LeaveEvent(s)
+}
+
+func (p Parser) LeaveEvent(s string) (e *event.LeaveEvent, err error) {
 	parts := strings.Split(s, " ")
 	if len(parts) != 3 {
-		err = fmt.Errorf("parse.LeaveEvent: %w", IncorrectEventFormat)
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
 		return
 	}
-	t, id, client, err := inputEvent(s)
+	t, id, client, err := p.inputEvent(s)
 	if err != nil {
-		err = fmt.Errorf("parse.LeaveEvent: %w", err)
 		return
 	}
 	if id != event.LeaveEventId {
-		err = fmt.Errorf("parse.LeaveEvent: incorrect id %w", IncorrectEventFormat)
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
 		return
 	}
 
 	return event.NewLeaveEvent(t, client), nil
 }
 
-func InputEvent(s string) (e event.InputEvent, err error) {
+func PromotionEvent(s string) (*event.PromotionEvent, error) {
+	return Parser{}.PromotionEvent(s)
+}
+
+// PromotionEvent parses "<time> 7 <client> <promotion>".
+func (p Parser) PromotionEvent(s string) (e *event.PromotionEvent, err error) {
+	parts := strings.Split(s, " ")
+	if len(parts) != 4 {
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
+		return
+	}
+	t, id, client, err := p.inputEvent(s)
+	if err != nil {
+		return
+	}
+	if id != event.PromotionEventId {
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
+		return
+	}
+
+	if _, err = promo.Parse(parts[3]); err != nil {
+		err = NewParseError(s, "promotion", 3, errors.Unwrap(err))
+		return
+	}
+	return event.NewPromotionEvent(t, client, parts[3]), nil
+}
+
+func InputEvent(s string) (event.InputEvent, error) {
+	return Parser{}.InputEvent(s)
+}
+
+func (p Parser) InputEvent(s string) (e event.InputEvent, err error) {
 	s = strings.Trim(s, " ")
-	_, id, _, err := inputEvent(s)
+	_, id, err := p.eventHead(s)
 	if err != nil {
 		return event.EmptyInputEvent, err
 	}
+	// corrections have a sequence number in place of the client
+	if id != event.VoidEventId && id != event.AmendEventId {
+		if _, _, _, err := p.inputEvent(s); err != nil {
+			return event.EmptyInputEvent, err
+		}
+	}
 
 	var errParse error
 	switch id {
 	case event.ArrivalEventId:
-		e, errParse = ArriveEvent(s)
+		e, errParse = p.ArriveEvent(s)
 	case event.SitDownEventId:
-		e, errParse = SitDownEvent(s)
+		e, errParse = p.SitDownEvent(s)
 	case event.WaitEventId:
-		e, errParse = WaitEvent(s)
+		e, errParse = p.WaitEvent(s)
 	case event.LeaveEventId:
-		e, errParse = LeaveEvent(s)
+		e, errParse = p.LeaveEvent(s)
+	case event.VoidEventId:
+		e, errParse = p.VoidEvent(s)
+	case event.AmendEventId:
+		e, errParse = p.AmendEvent(s)
+	case event.PromotionEventId:
+		e, errParse = p.PromotionEvent(s)
 	default:
-		return event.EmptyInputEvent, IncorrectEventFormat
+		return event.EmptyInputEvent, NewParseError(s, "id", 1, IncorrectEventFormat)
 	}
 
 	if errParse != nil {
@@ -205,7 +343,78 @@ func InputEvent(s string) (e event.InputEvent, err error) {
 	return e, err
 }
 
+func VoidEvent(s string) (*event.VoidEvent, error) {
+	return Parser{}.VoidEvent(s)
+}
+
+// VoidEvent parses "<time> 5 <sequence number>".
+func (p Parser) VoidEvent(s string) (e *event.VoidEvent, err error) {
+	parts := strings.Split(s, " ")
+	if len(parts) != 3 {
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
+		return
+	}
+	t, id, err := p.eventHead(s)
+	if err != nil {
+		return
+	}
+	if id != event.VoidEventId {
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
+		return
+	}
+
+	seq, err := positiveNumber(parts[2])
+	if err != nil {
+		err = NewParseError(s, "seq", 2, err)
+		return
+	}
+	return event.NewVoidEvent(t, seq), nil
+}
+
+func AmendEvent(s string) (*event.AmendEvent, error) {
+	return Parser{}.AmendEvent(s)
+}
+
+// AmendEvent parses "<time> 6 <sequence number> <client> [<table>]".
+func (p Parser) AmendEvent(s string) (e *event.AmendEvent, err error) {
+	parts := strings.Split(s, " ")
+	if len(parts) != 4 && len(parts) != 5 {
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
+		return
+	}
+	t, id, err := p.eventHead(s)
+	if err != nil {
+		return
+	}
+	if id != event.AmendEventId {
+		err = NewParseError(s, "id", 1, IncorrectEventFormat)
+		return
+	}
+
+	seq, err := positiveNumber(parts[2])
+	if err != nil {
+		err = NewParseError(s, "seq", 2, err)
+		return
+	}
+	client, err := p.Names.ClientName(parts[3])
+	if err != nil {
+		err = NewParseError(s, "client", 3, err)
+		return
+	}
+	var table int
+	if len(parts) == 5 {
+		if table, err = positiveNumber(parts[4]); err != nil {
+			err = NewParseError(s, "table", 4, err)
+			return
+		}
+	}
+	return event.NewAmendEvent(t, seq, client, table), nil
+}
+
 func positiveNumber(s string) (int, error) {
+	if strings.HasPrefix(s, "+") {
+		return 0, strconv.ErrSyntax
+	}
 	n, err := strconv.Atoi(s)
 
 	if err != nil {
@@ -218,33 +427,51 @@ func positiveNumber(s string) (int, error) {
 	return n, nil
 }
 
-func clientName(s string) (string, error) {
-	match, _ := regexp.MatchString(`^[a-z | 1-9 |\_|\-]+$`, s)
-	if !match {
-		return "", IncorrectClientNameFormat
+// isDigits reports whether s is a non-empty string of 0..9. Atoi alone
+// accepts a sign.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
 	}
-	return s, nil
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
 }
 
-func inputEvent(s string) (t store.DayTime, id int, client string, err error) {
-	parts := strings.Split(s, " ")
-	if len(parts) < 3 {
+func (p Parser) inputEvent(s string) (t store.DayTime, id int, client string, err error) {
+	t, id, err = p.eventHead(s)
+	if err != nil {
 		return
 	}
 
-	t, err = DayTime(parts[0])
+	client, err = p.Names.ClientName(strings.Split(s, " ")[2])
 	if err != nil {
+		err = NewParseError(s, "client", 2, err)
 		return
 	}
+	return t, id, client, nil
+}
 
-	id, err = positiveNumber(parts[1])
-	if err != nil {
+// eventHead parses the time and the id of an event.
+func (p Parser) eventHead(s string) (t store.DayTime, id int, err error) {
+	parts := strings.Split(s, " ")
+	if len(parts) < 3 {
+		err = NewParseError(s, "event", -1, IncorrectEventFormat)
 		return
 	}
 
-	client, err = clientName(parts[2])
+	t, err = p.DayTime(parts[0])
 	if err != nil {
+		err = reposition(err, s, "time", 0)
 		return
 	}
-	return t, id, client, nil
+
+	id, err = positiveNumber(parts[1])
+	if err != nil {
+		err = NewParseError(s, "id", 1, err)
+	}
+	return
 }
@@ -1,10 +1,16 @@
 package parse_test
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
 	"github.com/GerogeGol/yadro-test-problem/domain/test"
@@ -36,6 +42,11 @@ func TestParseDayTime(t *testing.T) {
 			{"00:00", store.NewDayTime(0, 0)},
 			{"23:59", store.NewDayTime(23, 59)},
 			{"15:00", store.NewDayTime(15, 0)},
+			{"09:00:30", store.NewDayTimeSeconds(9, 0, 30)},
+			{"23:59:59", store.NewDayTimeSeconds(23, 59, 59)},
+			{"2024-05-01T09:00:30Z", store.NewDayTimeSeconds(9, 0, 30)},
+			{"2024-05-01T09:00:30.250+03:00", store.NewDayTimeSeconds(6, 0, 30)},
+			{"2024-05-01T01:00:00-08:00", store.NewDayTimeSeconds(9, 0, 0)},
 		}
 		for i, c := range cases {
 			t.Run(fmt.Sprintf("Case %d: %q", i, c.input), func(t *testing.T) {
@@ -51,6 +62,15 @@ func TestParseDayTime(t *testing.T) {
 			"25:00",
 			"09:90",
 			"24:00",
+			"09:60",
+			"+9:00",
+			"-1:30",
+			"09:+5",
+			"09:00:60",
+			"09:00:5",
+			"09:00:00:00",
+			"2024-05-01T25:00:00Z",
+			"2024-05-01T09:00:00",
 		}
 
 		for i, c := range cases {
@@ -60,6 +80,29 @@ func TestParseDayTime(t *testing.T) {
 			})
 		}
 	})
+	t.Run("on the club day", func(t *testing.T) {
+		moscow, err := time.LoadLocation("Europe/Moscow")
+		test.AssertNoError(t, err)
+		p := parse.Parser{Clock: store.Clock{Day: time.Date(2024, 5, 1, 0, 0, 0, 0, moscow)}}
+
+		d, err := p.DayTime("09:00")
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, d.Time.Equal(time.Date(2024, 5, 1, 6, 0, 0, 0, time.UTC)))
+
+		d, err = p.DayTime("2024-05-01T09:00:30.250Z")
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, d.String(), "12:00:30")
+
+		d, err = p.DayTime("2024-04-30T23:00:00Z")
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, d.String(), "02:00:00")
+
+		_, err = p.DayTime("2024-05-02T09:00:00+03:00")
+		test.AssertError(t, err, store.OtherDay)
+
+		_, err = p.DayTime("9:00")
+		test.AssertError(t, err, parse.IncorrectDayTimeFormat)
+	})
 }
 
 func TestParseClubWorkingTime(t *testing.T) {
@@ -77,8 +120,8 @@ func TestParseClubWorkingTime(t *testing.T) {
 			t.Run(fmt.Sprintf("Case %d: %s", i, c.input), func(t *testing.T) {
 				openTime, closeTime, err := parse.ClubWorkingTime(c.input)
 				test.AssertNoError(t, err)
-				test.AssertEqual(t, openTime, c.openTime)
-				test.AssertEqual(t, closeTime, c.closeTime)
+				test.AssertDayTime(t, openTime, c.openTime)
+				test.AssertDayTime(t, closeTime, c.closeTime)
 			})
 		}
 	})
@@ -114,6 +157,12 @@ func TestParseHourCost(t *testing.T) {
 
 		_, err = parse.HourCost("0")
 		test.AssertError(t, err, parse.LessOrEqualZeroError)
+
+		_, err = parse.HourCost("NaN")
+		test.AssertError(t, err, strconv.ErrSyntax)
+
+		_, err = parse.HourCost("Inf")
+		test.AssertError(t, err, strconv.ErrSyntax)
 	})
 }
 
@@ -131,7 +180,7 @@ func TestParseArriveEvent(t *testing.T) {
 			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
 				e, err := parse.ArriveEvent(c.input)
 				test.AssertNoError(t, err)
-				test.AssertEqual(t, e.Time(), c.ev.Time())
+				test.AssertDayTime(t, e.Time(), c.ev.Time())
 				test.AssertEqual(t, e.Id(), c.ev.Id())
 				test.AssertEqual(t, e.Client(), c.ev.Client())
 			})
@@ -172,7 +221,7 @@ func TestParseSitDownEvent(t *testing.T) {
 			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
 				e, err := parse.SitDownEvent(c.input)
 				test.AssertNoError(t, err)
-				test.AssertEqual(t, e.Time(), c.ev.Time())
+				test.AssertDayTime(t, e.Time(), c.ev.Time())
 				test.AssertEqual(t, e.Id(), c.ev.Id())
 				test.AssertEqual(t, e.Client(), c.ev.Client())
 				test.AssertEqual(t, e.Table(), c.ev.Table())
@@ -195,7 +244,7 @@ func TestParseWaitEvent(t *testing.T) {
 			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
 				e, err := parse.WaitEvent(c.input)
 				test.AssertNoError(t, err)
-				test.AssertEqual(t, e.Time(), c.ev.Time())
+				test.AssertDayTime(t, e.Time(), c.ev.Time())
 				test.AssertEqual(t, e.Id(), c.ev.Id())
 				test.AssertEqual(t, e.Client(), c.ev.Client())
 			})
@@ -217,7 +266,7 @@ func TestParseLeaveEvent(t *testing.T) {
 			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
 				e, err := parse.LeaveEvent(c.input)
 				test.AssertNoError(t, err)
-				test.AssertEqual(t, e.Time(), c.ev.Time())
+				test.AssertDayTime(t, e.Time(), c.ev.Time())
 				test.AssertEqual(t, e.Id(), c.ev.Id())
 				test.AssertEqual(t, e.Client(), c.ev.Client())
 			})
@@ -235,14 +284,20 @@ func TestParseEvent(t *testing.T) {
 			{"08:48 2 client1 2", *event.NewSitDownEvent(store.NewDayTime(8, 48), "client1", 1)},
 			{"08:48 3 client1", *event.NewWaitEvent(store.NewDayTime(8, 48), "client1")},
 			{"12:48 4 client2", *event.NewLeaveEvent(store.NewDayTime(12, 48), "client2")},
+			{"12:50 5 4", event.NewVoidEvent(store.NewDayTime(12, 50), 4)},
+			{"12:50 6 4 client2", event.NewAmendEvent(store.NewDayTime(12, 50), 4, "client2", 0)},
+			{"12:50 6 4 client2 3", event.NewAmendEvent(store.NewDayTime(12, 50), 4, "client2", 3)},
+			{"12:50 7 client2 -10%", event.NewPromotionEvent(store.NewDayTime(12, 50), "client2", "-10%")},
+			{"12:50 7 client2 3for2", event.NewPromotionEvent(store.NewDayTime(12, 50), "client2", "3for2")},
 		}
 
 		for i, c := range cases {
 			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
 				e, err := parse.InputEvent(c.input)
 				test.AssertNoError(t, err)
-				test.AssertEqual(t, e.Time(), c.ev.Time())
+				test.AssertDayTime(t, e.Time(), c.ev.Time())
 				test.AssertEqual(t, e.Id(), c.ev.Id())
+				test.AssertEqual(t, fmt.Sprint(e), c.input)
 			})
 		}
 	})
@@ -255,6 +310,12 @@ func TestParseEvent(t *testing.T) {
 			{"08:48 2 client1!"},
 			{"8:48 3 client1"},
 			{"12:8 4 client2"},
+			{"12:50 5 client2"},
+			{"12:50 5 4 client2"},
+			{"12:50 6 4"},
+			{"12:50 6 4 client2 0"},
+			{"12:50 7 client2"},
+			{"12:50 7 client2 -10% 1"},
 		}
 
 		for i, c := range cases {
@@ -266,3 +327,343 @@ func TestParseEvent(t *testing.T) {
 		}
 	})
 }
+
+func TestParseError(t *testing.T) {
+	t.Run("error points to the offending token", func(t *testing.T) {
+		cases := []struct {
+			input string
+			field string
+			token string
+			col   int
+			err   error
+		}{
+			{"08:48 2 client1 0", "table", "0", 17, parse.LessOrEqualZeroError},
+			{"08:48 1 client!", "client", "client!", 9, parse.IncorrectClientNameFormat},
+			{"8:48 1 client", "time", "8:48", 1, parse.IncorrectDayTimeFormat},
+			{"08:48 8 client", "id", "8", 7, parse.IncorrectEventFormat},
+			{"08:48 1 client 1", "event", "08:48 1 client 1", 1, parse.IncorrectEventFormat},
+			{"08:48 5 0", "seq", "0", 9, parse.LessOrEqualZeroError},
+			{"08:48 6 2 client!", "client", "client!", 11, parse.IncorrectClientNameFormat},
+			{"08:48 6 2 client -1", "table", "-1", 18, parse.LessOrEqualZeroError},
+			{"08:48 7 client 10%", "promotion", "10%", 16, promo.UnknownPromotion},
+		}
+
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
+				_, err := parse.InputEvent(c.input)
+				test.AssertError(t, err, c.err)
+
+				perr, ok := err.(*parse.ParseError)
+				test.AssertTrue(t, ok)
+				test.AssertEqual(t, perr.Field, c.field)
+				test.AssertEqual(t, perr.Token, c.token)
+				test.AssertEqual(t, perr.Col, c.col)
+				test.AssertEqual(t, perr.Raw, c.input)
+			})
+		}
+	})
+
+	t.Run("working time error points to the incorrect time", func(t *testing.T) {
+		_, _, err := parse.ClubWorkingTime("09:00 9:00")
+		perr, ok := err.(*parse.ParseError)
+		test.AssertTrue(t, ok)
+		test.AssertEqual(t, perr.Field, "close time")
+		test.AssertEqual(t, perr.Col, 7)
+		test.AssertError(t, err, parse.IncorrectDayTimeFormat)
+	})
+
+	t.Run("diagnostic", func(t *testing.T) {
+		_, err := parse.InputEvent("08:48 1 client!")
+		perr := err.(*parse.ParseError)
+		perr.Line = 5
+
+		want := "in.txt:5:9: client \"client!\": " + parse.IncorrectClientNameFormat.Error() + "\n" +
+			"08:48 1 client!\n" +
+			"        ^~~~~~~\n"
+		test.AssertEqual(t, perr.Diagnostic("in.txt"), want)
+	})
+}
+
+func TestParseJSONInputEvent(t *testing.T) {
+	t.Run("parse correct events", func(t *testing.T) {
+		cases := []struct {
+			input string
+			want  string
+		}{
+			{`{"time": "08:48", "id": 1, "client": "client1"}`, "08:48 1 client1"},
+			{`{"time": "08:48", "id": 2, "client": "client1", "table": 2}`, "08:48 2 client1 2"},
+			{`{"id": 3, "client": "client1", "time": "08:48"}`, "08:48 3 client1"},
+			{`{"time": "12:48", "id": 4, "client": "client2"}`, "12:48 4 client2"},
+			{`{"time": "12:50", "id": 5, "seq": 4}`, "12:50 5 4"},
+			{`{"time": "12:50", "id": 6, "seq": 4, "client": "client2", "table": 3}`, "12:50 6 4 client2 3"},
+			{`{"time": "12:50", "id": 7, "client": "client2", "promotion": "first-hour-free"}`, "12:50 7 client2 first-hour-free"},
+		}
+
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
+				e, err := parse.JSONInputEvent(c.input)
+				test.AssertNoError(t, err)
+
+				text, err := parse.InputEvent(c.want)
+				test.AssertNoError(t, err)
+				test.AssertEqual(t, fmt.Sprint(e), fmt.Sprint(text))
+			})
+		}
+	})
+
+	t.Run("incorrect events", func(t *testing.T) {
+		cases := []struct {
+			input string
+			field string
+			token string
+			col   int
+			err   error
+		}{
+			{`{"time": "08:48", "id": 2, "client": "client1", "table": 0}`, "table", "0", 58, parse.LessOrEqualZeroError},
+			{`{"time": "08:48", "id": 2, "client": "client1"}`, "table", `{"time": "08:48", "id": 2, "client": "client1"}`, 1, parse.IncorrectEventFormat},
+			{`{"time": "08:48", "id": 1, "client": "client1", "table": 1}`, "table", "1", 58, parse.IncorrectEventFormat},
+			{`{"time": "08:48", "id": 1, "client": "client!"}`, "client", "client!", 39, parse.IncorrectClientNameFormat},
+			{`{"time": "8:48", "id": 1, "client": "client"}`, "time", "8:48", 11, parse.IncorrectDayTimeFormat},
+			{`{"time": "08:48", "id": 8, "client": "client"}`, "id", "8", 25, parse.IncorrectEventFormat},
+			{`{"time": "08:48", "id": "1", "client": "client"}`, "id", "1", 26, parse.IncorrectJSONFormat},
+			{`{"time": "08:48", "id": 1, "name": "client"}`, "event", `{"time": "08:48", "id": 1, "name": "client"}`, 1, parse.IncorrectJSONFormat},
+			{`08:48 1 client`, "event", "08:48 1 client", 1, parse.IncorrectJSONFormat},
+			{`{"time": "08:48", "id": 1, "seq": 1, "client": "client"}`, "seq", "1", 35, parse.IncorrectEventFormat},
+			{`{"time": "08:48", "id": 5, "seq": 0}`, "seq", "0", 35, parse.LessOrEqualZeroError},
+			{`{"time": "08:48", "id": 7, "client": "client"}`, "promotion", `{"time": "08:48", "id": 7, "client": "client"}`, 1, parse.IncorrectEventFormat},
+			{`{"time": "08:48", "id": 7, "client": "client", "promotion": "free"}`, "promotion", "free", 62, promo.UnknownPromotion},
+			{`{"time": "08:48", "id": 1, "client": "client", "promotion": "-5"}`, "promotion", "-5", 62, parse.IncorrectEventFormat},
+		}
+
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
+				e, err := parse.JSONInputEvent(c.input)
+				test.AssertError(t, err, c.err)
+				test.AssertEmptyEvent(t, e)
+
+				perr, ok := err.(*parse.ParseError)
+				test.AssertTrue(t, ok)
+				test.AssertEqual(t, perr.Field, c.field)
+				test.AssertEqual(t, perr.Token, c.token)
+				test.AssertEqual(t, perr.Col, c.col)
+			})
+		}
+	})
+}
+
+func TestParseJSONHeader(t *testing.T) {
+	t.Run("correct header", func(t *testing.T) {
+		h, err := parse.JSONHeader(`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10}`)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, h.TablesCount, 3)
+		test.AssertDayTime(t, h.OpenTime, store.NewDayTime(9, 0))
+		test.AssertDayTime(t, h.CloseTime, store.NewDayTime(19, 0))
+		test.AssertEqual(t, h.HourCost, 10.0)
+		test.AssertEqual(t, h.QueueCapacity, 0)
+		test.AssertTrue(t, h.Location == nil)
+	})
+
+	t.Run("club settings", func(t *testing.T) {
+		h, err := parse.JSONHeader(`{"tables": 3, "open_time": "22:00", "close_time": "06:00", "hour_cost": 10, "queue_capacity": 5, "time_zone": "Europe/Moscow"}`)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, h.QueueCapacity, 5)
+		test.AssertEqual(t, h.Location.String(), "Europe/Moscow")
+		test.AssertDayTime(t, h.CloseTime, store.NewDayTime(6, 0))
+	})
+
+	t.Run("incorrect header", func(t *testing.T) {
+		cases := []struct {
+			input string
+			err   error
+		}{
+			{`{"tables": 3, "open_time": "09:00", "close_time": "19:00"}`, parse.IncorrectJSONFormat},
+			{`{"tables": 0, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10}`, parse.LessOrEqualZeroError},
+			{`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": -1}`, parse.LessOrEqualZeroError},
+			{`{"tables": 3, "open_time": "9:00", "close_time": "19:00", "hour_cost": 10}`, parse.IncorrectDayTimeFormat},
+			{`{"tables": 3, "open_time": "20:00", "close_time": "19:00", "hour_cost": 10}`, parse.OpenTimeIsAfterCloseTimeError},
+			{`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10, "queue_capacity": -1}`, parse.IncorrectJSONFormat},
+			{`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10, "time_zone": "Mars/Olympus"}`, parse.UnknownTimeZone},
+			{`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10, "time_zone": ""}`, parse.UnknownTimeZone},
+			{`{"tables": 3, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10, "shifts": []}`, parse.IncorrectJSONFormat},
+		}
+
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
+				_, err := parse.JSONHeader(c.input)
+				test.AssertError(t, err, c.err)
+			})
+		}
+	})
+}
+
+func TestNamePolicy(t *testing.T) {
+	t.Run("task policy accepts exactly a..z, 0..9, '_' and '-'", func(t *testing.T) {
+		for b := 0; b < utf8.RuneSelf; b++ {
+			r := rune(b)
+			want := r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+
+			_, err := parse.NamePolicy{}.ClientName("client" + string(r))
+			if want && err != nil {
+				t.Fatalf("%q is rejected: %v", r, err)
+			}
+			if !want {
+				test.AssertError(t, err, parse.IncorrectClientNameFormat)
+			}
+		}
+	})
+
+	cases := []struct {
+		policy parse.NamePolicy
+		input  string
+		want   string
+		err    error
+	}{
+		{parse.NamePolicy{}, "client0", "client0", nil},
+		{parse.NamePolicy{}, "client 1", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{}, "client|1", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{}, "", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{}, "Client", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{}, "анна", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{}, "client\xff", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{FoldCase: true}, "Client_A", "client_a", nil},
+		{parse.NamePolicy{FoldCase: true}, "Анна", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{Unicode: true}, "Анна", "Анна", nil},
+		{parse.NamePolicy{Unicode: true}, "анна-2", "анна-2", nil},
+		{parse.NamePolicy{Unicode: true}, "анна!", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{Unicode: true}, "анна b", "", parse.IncorrectClientNameFormat},
+		{parse.NamePolicy{Unicode: true, FoldCase: true}, "АННА", "анна", nil},
+		{parse.NamePolicy{Unicode: true, MaxLength: 4}, "анна", "анна", nil},
+		{parse.NamePolicy{Unicode: true, MaxLength: 4}, "анна1", "", parse.ClientNameTooLong},
+		{parse.NamePolicy{MaxLength: 3}, "abcd", "", parse.ClientNameTooLong},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Case: %d, %+v %q", i, c.policy, c.input), func(t *testing.T) {
+			got, err := c.policy.ClientName(c.input)
+			if c.err != nil {
+				test.AssertError(t, err, c.err)
+				return
+			}
+			test.AssertNoError(t, err)
+			test.AssertEqual(t, got, c.want)
+		})
+	}
+
+	t.Run("message follows the policy", func(t *testing.T) {
+		_, err := parse.NamePolicy{}.ClientName("анна!")
+		test.AssertTrue(t, strings.Contains(err.Error(), "a..z"))
+		_, err = parse.NamePolicy{Unicode: true}.ClientName("анна!")
+		test.AssertFalse(t, strings.Contains(err.Error(), "a..z"))
+		test.AssertError(t, err, parse.IncorrectClientNameFormat)
+	})
+
+	t.Run("parser uses its policy", func(t *testing.T) {
+		p := parse.Parser{Names: parse.NamePolicy{Unicode: true, FoldCase: true}}
+		e, err := p.InputEvent("09:00 2 Анна 1")
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, e.Client(), "анна")
+
+		e, err = p.JSONInputEvent(`{"time": "09:00", "id": 1, "client": "Анна"}`)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, e.Client(), "анна")
+	})
+}
+
+func FuzzClientName(f *testing.F) {
+	for _, seed := range []string{"client1", "client0", "client 1", "Анна", "a|b", "A_b-9", "\xff"} {
+		f.Add(seed, false, false, 0)
+	}
+	f.Add("Анна", true, true, 4)
+
+	f.Fuzz(func(t *testing.T, s string, unicode, foldCase bool, maxLength int) {
+		policy := parse.NamePolicy{Unicode: unicode, FoldCase: foldCase, MaxLength: maxLength}
+		name, err := policy.ClientName(s)
+		if err != nil {
+			return
+		}
+
+		if name == "" || strings.ContainsAny(name, " |\t\n") {
+			t.Fatalf("%q is accepted as %q", s, name)
+		}
+		if maxLength > 0 && utf8.RuneCountInString(name) > maxLength {
+			t.Fatalf("%q is longer than %d", name, maxLength)
+		}
+		if foldCase && name != strings.ToLower(name) {
+			t.Fatalf("%q is not folded", name)
+		}
+		if again, err := policy.ClientName(name); err != nil || again != name {
+			t.Fatalf("%q is not stable: %q, %v", name, again, err)
+		}
+		if !unicode && !foldCase && name != s {
+			t.Fatalf("%q is changed to %q", s, name)
+		}
+	})
+}
+
+func FuzzDayTime(f *testing.F) {
+	for _, seed := range []string{"09:00", "00:00", "23:59", "24:00", "09:60", "+9:00", "9:00", "09:00:30", "2024-05-01T09:00:30+03:00"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		d, err := parse.DayTime(s)
+		if err != nil {
+			return
+		}
+		if !strings.ContainsAny(s, "Tt") && d.String() != s {
+			t.Fatalf("%q is parsed as %q", s, d)
+		}
+
+		again, err := parse.DayTime(d.String())
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, again, d)
+	})
+}
+
+func FuzzClubWorkingTime(f *testing.F) {
+	for _, seed := range []string{"09:00 19:00", "19:00 09:00", "00:00 23:59", "09:00  19:00", "09:00"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		openTime, closeTime, err := parse.ClubWorkingTime(s)
+		if err != nil {
+			return
+		}
+		if openTime.After(closeTime.Time) {
+			t.Fatalf("%q opens at %s after it closes at %s", s, openTime, closeTime)
+		}
+
+		again := fmt.Sprint(openTime, " ", closeTime)
+		openAgain, closeAgain, err := parse.ClubWorkingTime(again)
+		test.AssertNoError(t, err)
+		test.AssertDayTime(t, openAgain, openTime)
+		test.AssertDayTime(t, closeAgain, closeTime)
+	})
+}
+
+func FuzzInputEvent(f *testing.F) {
+	for _, seed := range []string{"08:48 1 client1", "09:54 2 client1 1", "09:52 3 client1", "12:33 4 client1", "15:30 1 client6 ", "08:48 2 client1 0", "08:48 5 client"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, s string) {
+		e, err := parse.InputEvent(s)
+		if err != nil {
+			test.AssertEmptyEvent(t, e)
+			var perr *parse.ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("%q: %v is not a ParseError", s, err)
+			}
+			return
+		}
+
+		again, err := parse.InputEvent(fmt.Sprint(e))
+		if err != nil {
+			t.Fatalf("%q is printed as %q which doesn't parse: %v", s, e, err)
+		}
+		test.AssertEqual(t, fmt.Sprint(again), fmt.Sprint(e))
+		test.AssertEqual(t, again.Id(), e.Id())
+		test.AssertDayTime(t, again.Time(), e.Time())
+		test.AssertEqual(t, again.Client(), e.Client())
+	})
+}
@@ -0,0 +1,150 @@
+package promo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var UnknownPromotion = fmt.Errorf("unknown promotion. Should be '-N%%', '-N', 'first-hour-free', '3for2' or 'points'")
+
+// Session is what a client pays for: whole hours at the hour cost, and the
+// loyalty points the client has with what they are worth.
+type Session struct {
+	Hours    int
+	HourCost float64
+	Points   int
+	Loyalty  Loyalty
+}
+
+// Payment returns the money due for the session without a promotion.
+func (s Session) Payment() float64 {
+	return float64(s.Hours) * s.HourCost
+}
+
+// Promotion lowers the payment for a session.
+type Promotion interface {
+	// Discount returns the money taken off the payment, at most the
+	// payment itself, and the points of the client spent on it.
+	Discount(s Session) (discount float64, spent int)
+	String() string
+}
+
+// Percent takes a part of the payment off, in percent.
+type Percent float64
+
+func (p Percent) Discount(s Session) (float64, int) {
+	return s.Payment() * float64(p) / 100, 0
+}
+
+func (p Percent) String() string {
+	return "-" + strconv.FormatFloat(float64(p), 'f', -1, 64) + "%"
+}
+
+// Fixed takes an amount of money off the payment.
+type Fixed float64
+
+func (f Fixed) Discount(s Session) (float64, int) {
+	return min(float64(f), s.Payment()), 0
+}
+
+func (f Fixed) String() string {
+	return "-" + strconv.FormatFloat(float64(f), 'f', -1, 64)
+}
+
+// FirstHourFree doesn't charge the first hour.
+type FirstHourFree struct{}
+
+func (FirstHourFree) Discount(s Session) (float64, int) {
+	return float64(min(s.Hours, 1)) * s.HourCost, 0
+}
+
+func (FirstHourFree) String() string {
+	return "first-hour-free"
+}
+
+// ThreeForTwo doesn't charge every third hour.
+type ThreeForTwo struct{}
+
+func (ThreeForTwo) Discount(s Session) (float64, int) {
+	return float64(s.Hours/3) * s.HourCost, 0
+}
+
+func (ThreeForTwo) String() string {
+	return "3for2"
+}
+
+// Points pays for whole hours with the loyalty points of the client.
+type Points struct{}
+
+func (Points) Discount(s Session) (float64, int) {
+	free, spent := s.Loyalty.Redeem(s.Hours, s.Points)
+	return float64(free) * s.HourCost, spent
+}
+
+func (Points) String() string {
+	return "points"
+}
+
+// Parse returns the promotion written as s: "-10%" for ten percent off,
+// "-50" for 50 off, "first-hour-free", "3for2" or "points".
+func Parse(s string) (Promotion, error) {
+	switch s {
+	case "first-hour-free":
+		return FirstHourFree{}, nil
+	case "3for2":
+		return ThreeForTwo{}, nil
+	case "points":
+		return Points{}, nil
+	}
+
+	amount, ok := strings.CutPrefix(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("promo.Parse: %q: %w", s, UnknownPromotion)
+	}
+	amount, percent := strings.CutSuffix(amount, "%")
+	// the sign and exponents are not for money
+	if strings.ContainsAny(amount, "+-eEpPxX_") {
+		return nil, fmt.Errorf("promo.Parse: %q: %w", s, UnknownPromotion)
+	}
+	n, err := strconv.ParseFloat(amount, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) || math.IsNaN(n) || percent && n > 100 {
+		return nil, fmt.Errorf("promo.Parse: %q: %w", s, UnknownPromotion)
+	}
+	if percent {
+		return Percent(n), nil
+	}
+	return Fixed(n), nil
+}
+
+// Loyalty is how the clients earn points for the hours they pay for and
+// spend them on free hours.
+type Loyalty struct {
+	// PointsPerHour are earned for every whole hour paid for, zero turns
+	// the points off.
+	PointsPerHour int
+	// HourPrice is the points a free hour costs.
+	HourPrice int
+}
+
+// DefaultLoyalty gives a point for an hour and an hour for ten points.
+var DefaultLoyalty = Loyalty{PointsPerHour: 1, HourPrice: 10}
+
+// Redeem returns the hours of the session paid for with points and the
+// points spent on them.
+func (l Loyalty) Redeem(hours int, points int) (free int, spent int) {
+	if l.HourPrice <= 0 {
+		return 0, 0
+	}
+	free = min(hours, points/l.HourPrice)
+	return free, free * l.HourPrice
+}
+
+// Earn returns the points for a payment.
+func (l Loyalty) Earn(payment float64, hourCost float64) int {
+	if hourCost <= 0 {
+		return 0
+	}
+	return int(math.Floor(payment/hourCost+1e-9)) * l.PointsPerHour
+}
@@ -0,0 +1,112 @@
+package promo_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("correct promotions", func(t *testing.T) {
+		cases := []struct {
+			input string
+			want  promo.Promotion
+		}{
+			{"-10%", promo.Percent(10)},
+			{"-12.5%", promo.Percent(12.5)},
+			{"-100%", promo.Percent(100)},
+			{"-50", promo.Fixed(50)},
+			{"first-hour-free", promo.FirstHourFree{}},
+			{"3for2", promo.ThreeForTwo{}},
+			{"points", promo.Points{}},
+		}
+
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("Case: %d, %q", i, c.input), func(t *testing.T) {
+				got, err := promo.Parse(c.input)
+				test.AssertNoError(t, err)
+				test.AssertEqual(t, got, c.want)
+				test.AssertEqual(t, got.String(), c.input)
+			})
+		}
+	})
+
+	t.Run("incorrect promotions", func(t *testing.T) {
+		cases := []string{"", "10%", "50", "-0", "-0%", "-101%", "--5", "-+5", "-1e3", "-0x10", "-NaN", "-Inf", "-5%%", "free"}
+
+		for i, input := range cases {
+			t.Run(fmt.Sprintf("Case: %d, %q", i, input), func(t *testing.T) {
+				_, err := promo.Parse(input)
+				test.AssertError(t, err, promo.UnknownPromotion)
+			})
+		}
+	})
+}
+
+func TestDiscount(t *testing.T) {
+	cases := []struct {
+		promotion promo.Promotion
+		hours     int
+		want      float64
+	}{
+		{promo.Percent(10), 3, 3},
+		{promo.Percent(100), 3, 30},
+		{promo.Fixed(15), 3, 15},
+		{promo.Fixed(50), 3, 30},
+		{promo.FirstHourFree{}, 3, 10},
+		{promo.FirstHourFree{}, 0, 0},
+		{promo.ThreeForTwo{}, 2, 0},
+		{promo.ThreeForTwo{}, 3, 10},
+		{promo.ThreeForTwo{}, 7, 20},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Case: %d, %v for %d hours", i, c.promotion, c.hours), func(t *testing.T) {
+			discount, spent := c.promotion.Discount(promo.Session{Hours: c.hours, HourCost: 10, Points: 100, Loyalty: promo.DefaultLoyalty})
+			test.AssertEqual(t, discount, c.want)
+			// only the points promotion spends points
+			test.AssertEqual(t, spent, 0)
+		})
+	}
+
+	t.Run("points", func(t *testing.T) {
+		loyalty := promo.Loyalty{PointsPerHour: 1, HourPrice: 5}
+		discount, spent := promo.Points{}.Discount(promo.Session{Hours: 3, HourCost: 10, Points: 12, Loyalty: loyalty})
+		test.AssertEqual(t, discount, 20)
+		test.AssertEqual(t, spent, 10)
+
+		discount, spent = promo.Points{}.Discount(promo.Session{Hours: 3, HourCost: 10, Points: 4, Loyalty: loyalty})
+		test.AssertEqual(t, discount, 0)
+		test.AssertEqual(t, spent, 0)
+	})
+}
+
+func TestLoyalty(t *testing.T) {
+	loyalty := promo.Loyalty{PointsPerHour: 2, HourPrice: 5}
+
+	t.Run("earn", func(t *testing.T) {
+		test.AssertEqual(t, loyalty.Earn(30, 10), 6)
+		// only whole hours paid for are counted
+		test.AssertEqual(t, loyalty.Earn(27, 10), 4)
+		test.AssertEqual(t, loyalty.Earn(0, 10), 0)
+		test.AssertEqual(t, promo.Loyalty{}.Earn(30, 10), 0)
+	})
+
+	t.Run("redeem", func(t *testing.T) {
+		free, spent := loyalty.Redeem(3, 12)
+		test.AssertEqual(t, free, 2)
+		test.AssertEqual(t, spent, 10)
+
+		free, spent = loyalty.Redeem(1, 12)
+		test.AssertEqual(t, free, 1)
+		test.AssertEqual(t, spent, 5)
+
+		free, spent = loyalty.Redeem(3, 4)
+		test.AssertEqual(t, free, 0)
+		test.AssertEqual(t, spent, 0)
+
+		free, _ = promo.Loyalty{}.Redeem(3, 100)
+		test.AssertEqual(t, free, 0)
+	})
+}
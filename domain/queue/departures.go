@@ -0,0 +1,53 @@
+package queue
+
+import "container/heap"
+
+// Departures is the queue of the clients at the tables by the time they
+// leave, the earliest first. The time is T, ordered by before.
+type Departures[T any] struct {
+	h departureHeap[T]
+}
+
+func NewDepartures[T any](before func(a, b T) bool) *Departures[T] {
+	return &Departures[T]{h: departureHeap[T]{before: before}}
+}
+
+func (d *Departures[T]) Push(at T, client string) {
+	heap.Push(&d.h, departure[T]{at: at, client: client})
+}
+
+// Next returns the earliest departure and leaves it in the queue.
+func (d *Departures[T]) Next() (at T, client string) {
+	return d.h.items[0].at, d.h.items[0].client
+}
+
+// Pop takes the earliest departure out of the queue.
+func (d *Departures[T]) Pop() (at T, client string) {
+	x := heap.Pop(&d.h).(departure[T])
+	return x.at, x.client
+}
+
+func (d *Departures[T]) Len() int {
+	return d.h.Len()
+}
+
+type departure[T any] struct {
+	at     T
+	client string
+}
+
+type departureHeap[T any] struct {
+	items  []departure[T]
+	before func(a, b T) bool
+}
+
+func (h departureHeap[T]) Len() int           { return len(h.items) }
+func (h departureHeap[T]) Less(i, j int) bool { return h.before(h.items[i].at, h.items[j].at) }
+func (h departureHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *departureHeap[T]) Push(x any)        { h.items = append(h.items, x.(departure[T])) }
+func (h *departureHeap[T]) Pop() any {
+	old := h.items
+	x := old[len(old)-1]
+	h.items = old[:len(old)-1]
+	return x
+}
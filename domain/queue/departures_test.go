@@ -0,0 +1,33 @@
+package queue_test
+
+import (
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestDepartures(t *testing.T) {
+	d := queue.NewDepartures(func(a, b int) bool { return a < b })
+	d.Push(30, "c")
+	d.Push(10, "a")
+	d.Push(20, "b")
+
+	t.Run("next", func(t *testing.T) {
+		at, client := d.Next()
+		test.AssertEqual(t, at, 10)
+		test.AssertEqual(t, client, "a")
+		test.AssertEqual(t, d.Len(), 3)
+	})
+	t.Run("pop the earliest first", func(t *testing.T) {
+		var got []string
+		for d.Len() > 0 {
+			_, client := d.Pop()
+			got = append(got, client)
+		}
+		test.AssertEqual(t, len(got), 3)
+		test.AssertEqual(t, got[0], "a")
+		test.AssertEqual(t, got[1], "b")
+		test.AssertEqual(t, got[2], "c")
+	})
+}
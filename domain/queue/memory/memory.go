@@ -2,6 +2,7 @@ package memqueue
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/GerogeGol/yadro-test-problem/domain/queue"
 )
@@ -36,3 +37,20 @@ func (q *Queue) Pop() error {
 func (q *Queue) Len() int {
 	return len(q.q)
 }
+
+func (q *Queue) Contains(s string) bool {
+	return slices.Contains(q.q, s)
+}
+
+func (q *Queue) Remove(s string) bool {
+	i := slices.Index(q.q, s)
+	if i < 0 {
+		return false
+	}
+	q.q = slices.Delete(q.q, i, i+1)
+	return true
+}
+
+func (q *Queue) Values() []string {
+	return slices.Clone(q.q)
+}
@@ -28,4 +28,28 @@ func TestQueue(t *testing.T) {
 		err = q.Pop()
 		test.AssertError(t, err, queue.QueueIsEmpty)
 	})
+	t.Run("remove", func(t *testing.T) {
+		q.Push("a")
+		q.Push("b")
+		q.Push("c")
+
+		test.AssertTrue(t, q.Contains("b"))
+		test.AssertTrue(t, q.Remove("b"))
+		test.AssertFalse(t, q.Contains("b"))
+		test.AssertFalse(t, q.Remove("b"))
+		test.AssertEqual(t, q.Len(), 2)
+
+		val, _ := q.Top()
+		test.AssertEqual(t, val, "a")
+	})
+	t.Run("values", func(t *testing.T) {
+		values := q.Values()
+		test.AssertEqual(t, len(values), 2)
+		test.AssertEqual(t, values[0], "a")
+		test.AssertEqual(t, values[1], "c")
+
+		values[0] = "changed"
+		val, _ := q.Top()
+		test.AssertEqual(t, val, "a")
+	})
 }
@@ -9,4 +9,10 @@ type Queue interface {
 	Push(value string)
 	Top() (string, bool)
 	Len() int
+	Contains(value string) bool
+	// Remove takes value out of the queue wherever it is and reports
+	// whether it was there.
+	Remove(value string) bool
+	// Values returns the values from the top of the queue to its end.
+	Values() []string
 }
@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/GerogeGol/yadro-test-problem/api/clubpb"
+	"github.com/GerogeGol/yadro-test-problem/domain/feed"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+// Server serves the Club API over a service. The events go through it one
+// at a time and in time order, as the lines of an input file do.
+type Server struct {
+	clubpb.UnimplementedClubServer
+	// Parser checks the times and the client names of the requests.
+	Parser parse.Parser
+
+	mu       sync.Mutex
+	service  *service.Service
+	lastTime store.DayTime
+	// sentAway tells that the club has been closed at its close time,
+	// closed that the day is over and no more requests are served.
+	sentAway bool
+	closed   bool
+}
+
+func NewServer(s *service.Service) *Server {
+	return &Server{service: s}
+}
+
+func (s *Server) Arrive(_ context.Context, r *clubpb.ClientRequest) (*clubpb.EventReply, error) {
+	return s.serve(r.GetTime(), r.GetClient(), func(t store.DayTime, client string) event.InputEvent {
+		return event.NewArrivalEvent(t, client)
+	})
+}
+
+func (s *Server) SitDown(_ context.Context, r *clubpb.SitDownRequest) (*clubpb.EventReply, error) {
+	if r.GetTable() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "table: %v", parse.LessOrEqualZeroError)
+	}
+	return s.serve(r.GetTime(), r.GetClient(), func(t store.DayTime, client string) event.InputEvent {
+		return event.NewSitDownEvent(t, client, int(r.GetTable()))
+	})
+}
+
+func (s *Server) Wait(_ context.Context, r *clubpb.ClientRequest) (*clubpb.EventReply, error) {
+	return s.serve(r.GetTime(), r.GetClient(), func(t store.DayTime, client string) event.InputEvent {
+		return event.NewWaitEvent(t, client)
+	})
+}
+
+func (s *Server) Leave(_ context.Context, r *clubpb.ClientRequest) (*clubpb.EventReply, error) {
+	return s.serve(r.GetTime(), r.GetClient(), func(t store.DayTime, client string) event.InputEvent {
+		return event.NewLeaveEvent(t, client)
+	})
+}
+
+func (s *Server) serve(timeStr, clientStr string, newEvent func(store.DayTime, string) event.InputEvent) (*clubpb.EventReply, error) {
+	t, err := s.Parser.DayTime(timeStr)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	client, err := s.Parser.Names.ClientName(clientStr)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "client: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, status.Error(codes.FailedPrecondition, "the club is closed for the day")
+	}
+	if t.Before(s.lastTime.Time) {
+		return nil, status.Errorf(codes.FailedPrecondition, "%v: %s is before %s", scan.EventTimeIsBeforePrevious, t, s.lastTime)
+	}
+	// the club closes before the first event past its close time, as it
+	// does for an input file, and answers the later events closed
+	if !s.sentAway && t.Compare(s.service.Club().CloseTime.Time) == 1 {
+		if _, err := s.service.Close(); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		s.sentAway = true
+	}
+
+	out := s.service.ServeEvent(newEvent(t, client))
+	if errEvent, ok := out.(*event.ErrorEvent); ok && !service.IsRecoverable(errEvent.Err()) {
+		return nil, status.Error(codes.InvalidArgument, errEvent.Err().Error())
+	}
+	s.lastTime = t
+
+	reply := &clubpb.EventReply{}
+	if !event.IsEmpty(out) {
+		reply.Event = toProto(out)
+	}
+	return reply, nil
+}
+
+func (s *Server) Close(context.Context, *clubpb.CloseRequest) (*clubpb.CloseReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, status.Error(codes.FailedPrecondition, "the club is closed for the day")
+	}
+
+	var events []event.OutLeaveEvent
+	if !s.sentAway {
+		var err error
+		if events, err = s.service.Close(); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	s.sentAway, s.closed = true, true
+
+	reply := &clubpb.CloseReply{}
+	for i := range events {
+		reply.Events = append(reply.Events, toProto(&events[i]))
+	}
+	return reply, nil
+}
+
+func (s *Server) TablesInfo(context.Context, *clubpb.TablesInfoRequest) (*clubpb.TablesInfoReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos, err := s.service.Profit()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	reply := &clubpb.TablesInfoReply{}
+	for _, info := range infos {
+		reply.Tables = append(reply.Tables, &clubpb.TableInfo{
+			Number:         int32(info.Number),
+			Profit:         info.Profit,
+			WorkingSeconds: int64(info.WorkingTime.Seconds()),
+			Gross:          info.Gross(),
+			Discount:       info.Discount,
+		})
+	}
+	return reply, nil
+}
+
+func (s *Server) State(context.Context, *clubpb.StateRequest) (*clubpb.StateReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cc := s.service.Club()
+	reply := &clubpb.StateReply{
+		OpenTime:      cc.OpenTime.String(),
+		CloseTime:     cc.CloseTime.String(),
+		HourCost:      cc.MoneyPerHour,
+		QueueCapacity: int32(cc.QueueCapacity),
+		Tables:        make([]*clubpb.Table, cc.ComputerCount),
+		Queue:         cc.Waiting(),
+	}
+	for i := range reply.Tables {
+		reply.Tables[i] = &clubpb.Table{Number: int32(i + 1)}
+	}
+
+	clients, err := cc.Clients()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for _, client := range clients {
+		if client.Table == 0 {
+			reply.Clients = append(reply.Clients, client.Name)
+			continue
+		}
+		table := reply.Tables[client.Table-1]
+		table.Busy = true
+		table.Client = client.Name
+		table.PlayingSince = client.PlayingSince.String()
+	}
+	return reply, nil
+}
+
+// Events streams the events until the client goes away. A client that
+// reads slower than the club generates events loses the oldest of them and
+// learns how many were lost so far from the dropped field of the next
+// event.
+func (s *Server) Events(r *clubpb.EventsRequest, stream clubpb.Club_EventsServer) error {
+	buffer := int(r.GetBuffer())
+	if buffer < 0 {
+		return status.Error(codes.InvalidArgument, "buffer can't be negative")
+	}
+	if buffer == 0 {
+		buffer = feed.DefaultBuffer
+	}
+
+	sub := s.service.Subscribe(buffer)
+	defer sub.Unsubscribe()
+
+	// the headers tell the client it is subscribed, events served after
+	// that reach it
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-sub.C():
+			if !ok {
+				return nil
+			}
+			pb := toProto(e)
+			pb.Dropped = sub.Dropped()
+			if err := stream.Send(pb); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProto(e event.Event) *clubpb.Event {
+	r := event.NewRecord(e)
+	pb := &clubpb.Event{
+		Time:   r.Time,
+		Id:     int32(r.ID),
+		Client: r.Client,
+		Table:  int32(r.Table),
+	}
+	if errEvent, ok := e.(*event.ErrorEvent); ok {
+		pb.Error = &clubpb.Error{Code: service.ErrorCode(errEvent.Err()), Name: r.Error}
+	}
+	return pb
+}
@@ -0,0 +1,258 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/GerogeGol/yadro-test-problem/api/clubpb"
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/rpc"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+// dial serves a club of tables over an in-process listener and returns a
+// client of it.
+func dial(t *testing.T, tables int) clubpb.ClubClient {
+	t.Helper()
+	return dialClub(t, service.NewComputerClub(tables, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue()))
+}
+
+func dialClub(t *testing.T, cc *service.ComputerClub) clubpb.ClubClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	clubpb.RegisterClubServer(server, rpc.NewServer(service.NewService(cc)))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	test.AssertNoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return clubpb.NewClubClient(conn)
+}
+
+func assertCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	test.AssertEqual(t, status.Code(err), code)
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a day at the club", func(t *testing.T) {
+		club := dial(t, 2)
+
+		reply, err := club.Arrive(ctx, &clubpb.ClientRequest{Time: "08:48", Client: "client1"})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, reply.GetEvent().GetId(), int32(13))
+		test.AssertEqual(t, reply.GetEvent().GetError().GetCode(), "CLUB_CLOSED")
+		test.AssertEqual(t, reply.GetEvent().GetError().GetName(), "NotOpenYet")
+
+		reply, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "09:41", Client: "client1"})
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, reply.GetEvent() == nil)
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "09:54", Client: "client1", Table: 1})
+		test.AssertNoError(t, err)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "10:25", Client: "client2"})
+		test.AssertNoError(t, err)
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "10:58", Client: "client2", Table: 2})
+		test.AssertNoError(t, err)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "11:00", Client: "client3"})
+		test.AssertNoError(t, err)
+		reply, err = club.Wait(ctx, &clubpb.ClientRequest{Time: "11:01", Client: "client3"})
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, reply.GetEvent() == nil)
+
+		state, err := club.State(ctx, &clubpb.StateRequest{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, state.GetOpenTime(), "09:00")
+		test.AssertEqual(t, state.GetHourCost(), 10.0)
+		test.AssertEqual(t, len(state.GetTables()), 2)
+		test.AssertTrue(t, state.GetTables()[0].GetBusy())
+		test.AssertEqual(t, state.GetTables()[0].GetClient(), "client1")
+		test.AssertEqual(t, state.GetTables()[0].GetPlayingSince(), "09:54")
+		test.AssertEqual(t, len(state.GetQueue()), 1)
+		test.AssertEqual(t, state.GetQueue()[0], "client3")
+		test.AssertEqual(t, state.GetClients()[0], "client3")
+
+		reply, err = club.Leave(ctx, &clubpb.ClientRequest{Time: "12:33", Client: "client1"})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, reply.GetEvent().GetId(), int32(12))
+		test.AssertEqual(t, reply.GetEvent().GetClient(), "client3")
+		test.AssertEqual(t, reply.GetEvent().GetTable(), int32(1))
+
+		closed, err := club.Close(ctx, &clubpb.CloseRequest{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(closed.GetEvents()), 2)
+		test.AssertEqual(t, closed.GetEvents()[0].GetTime(), "19:00")
+		test.AssertEqual(t, closed.GetEvents()[0].GetId(), int32(11))
+		test.AssertEqual(t, closed.GetEvents()[0].GetClient(), "client2")
+		test.AssertEqual(t, closed.GetEvents()[1].GetClient(), "client3")
+
+		info, err := club.TablesInfo(ctx, &clubpb.TablesInfoRequest{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, info.GetTables()[0].GetProfit(), 100.0)
+		test.AssertEqual(t, info.GetTables()[0].GetWorkingSeconds(), int64((2*60+39+6*60+27)*60))
+		test.AssertEqual(t, info.GetTables()[1].GetNumber(), int32(2))
+		test.AssertEqual(t, info.GetTables()[1].GetProfit(), 90.0)
+	})
+
+	t.Run("tables with discounts", func(t *testing.T) {
+		cc := service.NewComputerClub(1, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+		club := dialClub(t, cc)
+
+		_, err := club.Arrive(ctx, &clubpb.ClientRequest{Time: "09:00", Client: "client1"})
+		test.AssertNoError(t, err)
+		test.AssertNoError(t, cc.Promote(store.NewDayTime(9, 0), "client1", "-10%"))
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "09:00", Client: "client1", Table: 1})
+		test.AssertNoError(t, err)
+		_, err = club.Leave(ctx, &clubpb.ClientRequest{Time: "11:00", Client: "client1"})
+		test.AssertNoError(t, err)
+
+		info, err := club.TablesInfo(ctx, &clubpb.TablesInfoRequest{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, info.GetTables()[0].GetProfit(), 18.0)
+		test.AssertEqual(t, info.GetTables()[0].GetGross(), 20.0)
+		test.AssertEqual(t, info.GetTables()[0].GetDiscount(), 2.0)
+	})
+
+	t.Run("club closes at the close time", func(t *testing.T) {
+		club := dial(t, 1)
+
+		_, err := club.Arrive(ctx, &clubpb.ClientRequest{Time: "17:00", Client: "client1"})
+		test.AssertNoError(t, err)
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "17:00", Client: "client1", Table: 1})
+		test.AssertNoError(t, err)
+
+		reply, err := club.Leave(ctx, &clubpb.ClientRequest{Time: "21:00", Client: "client1"})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, reply.GetEvent().GetError().GetName(), "ClientUnknown")
+
+		info, err := club.TablesInfo(ctx, &clubpb.TablesInfoRequest{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, info.GetTables()[0].GetProfit(), 20.0)
+
+		closed, err := club.Close(ctx, &clubpb.CloseRequest{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(closed.GetEvents()), 0)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		club := dial(t, 2)
+
+		_, err := club.Arrive(ctx, &clubpb.ClientRequest{Time: "9:00", Client: "client1"})
+		assertCode(t, err, codes.InvalidArgument)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "09:00", Client: "Client1"})
+		assertCode(t, err, codes.InvalidArgument)
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "09:00", Client: "client1", Table: 0})
+		assertCode(t, err, codes.InvalidArgument)
+
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "10:00", Client: "client1"})
+		test.AssertNoError(t, err)
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "10:00", Client: "client1", Table: 3})
+		assertCode(t, err, codes.InvalidArgument)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "09:59", Client: "client2"})
+		assertCode(t, err, codes.FailedPrecondition)
+
+		_, err = club.Close(ctx, &clubpb.CloseRequest{})
+		test.AssertNoError(t, err)
+		_, err = club.Close(ctx, &clubpb.CloseRequest{})
+		assertCode(t, err, codes.FailedPrecondition)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "19:30", Client: "client2"})
+		assertCode(t, err, codes.FailedPrecondition)
+	})
+
+	t.Run("events stream", func(t *testing.T) {
+		club := dial(t, 1)
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := club.Events(streamCtx, &clubpb.EventsRequest{})
+		test.AssertNoError(t, err)
+		// the server sends the headers once it is subscribed
+		_, err = stream.Header()
+		test.AssertNoError(t, err)
+
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "08:00", Client: "client1"})
+		test.AssertNoError(t, err)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "09:00", Client: "client1"})
+		test.AssertNoError(t, err)
+		_, err = club.Wait(ctx, &clubpb.ClientRequest{Time: "09:01", Client: "client1"})
+		test.AssertNoError(t, err)
+		_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "09:02", Client: "client2"})
+		test.AssertNoError(t, err)
+		_, err = club.SitDown(ctx, &clubpb.SitDownRequest{Time: "09:03", Client: "client2", Table: 1})
+		test.AssertNoError(t, err)
+		_, err = club.Close(ctx, &clubpb.CloseRequest{})
+		test.AssertNoError(t, err)
+
+		want := []struct {
+			time   string
+			id     int32
+			client string
+			code   string
+		}{
+			{"08:00", 13, "", "CLUB_CLOSED"},
+			{"09:01", 13, "", "FREE_TABLE_AVAILABLE"},
+			{"19:00", 11, "client1", ""},
+			{"19:00", 11, "client2", ""},
+		}
+		for _, w := range want {
+			e, err := stream.Recv()
+			test.AssertNoError(t, err)
+			test.AssertEqual(t, e.GetTime(), w.time)
+			test.AssertEqual(t, e.GetId(), w.id)
+			test.AssertEqual(t, e.GetClient(), w.client)
+			test.AssertEqual(t, e.GetError().GetCode(), w.code)
+			test.AssertEqual(t, e.GetDropped(), uint64(0))
+		}
+	})
+
+	t.Run("slow stream reader", func(t *testing.T) {
+		club := dial(t, 1)
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := club.Events(streamCtx, &clubpb.EventsRequest{Buffer: 1})
+		test.AssertNoError(t, err)
+		_, err = stream.Header()
+		test.AssertNoError(t, err)
+
+		// whether the server gets to send an event before the next one is
+		// published is up to the scheduler, but every event is either
+		// received or counted as dropped
+		for i := 0; i < 3; i++ {
+			_, err = club.Arrive(ctx, &clubpb.ClientRequest{Time: "08:00", Client: "client1"})
+			test.AssertNoError(t, err)
+		}
+
+		var received, dropped uint64
+		for received+dropped < 3 {
+			e, err := stream.Recv()
+			test.AssertNoError(t, err)
+			test.AssertEqual(t, e.GetError().GetCode(), "CLUB_CLOSED")
+			received, dropped = received+1, e.GetDropped()
+		}
+		test.AssertEqual(t, received+dropped, uint64(3))
+	})
+
+	t.Run("negative buffer", func(t *testing.T) {
+		stream, err := dial(t, 1).Events(ctx, &clubpb.EventsRequest{Buffer: -1})
+		test.AssertNoError(t, err)
+		_, err = stream.Recv()
+		assertCode(t, err, codes.InvalidArgument)
+	})
+}
@@ -0,0 +1,122 @@
+package scan
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// FollowReader reads a growing input like 'tail -f': at the end of input it
+// keeps polling for new data instead of returning io.EOF. Once ctx is done it
+// hands out whatever is still readable and then reports io.EOF, so a scanner
+// on top of it finishes normally. Only a regular file grows: the end of a
+// pipe or a terminal is the end of input.
+type FollowReader struct {
+	ctx      context.Context
+	interval time.Duration
+	chunks   chan chunk
+	stop     chan struct{}
+	buf      []byte
+	eof      bool
+	// closed tells that io.EOF from the reader is final
+	closed bool
+}
+
+type chunk struct {
+	data []byte
+	err  error
+}
+
+func NewFollowReader(ctx context.Context, r io.Reader, interval time.Duration) *FollowReader {
+	f := &FollowReader{
+		ctx:      ctx,
+		interval: interval,
+		chunks:   make(chan chunk),
+		stop:     make(chan struct{}),
+	}
+	if file, ok := r.(interface{ Stat() (fs.FileInfo, error) }); ok {
+		if info, err := file.Stat(); err == nil && !info.Mode().IsRegular() {
+			f.closed = true
+		}
+	}
+	go f.pump(r)
+	return f
+}
+
+func (f *FollowReader) Read(p []byte) (int, error) {
+	for len(f.buf) == 0 {
+		if f.eof {
+			return 0, io.EOF
+		}
+		c, ok := f.next()
+		if !ok {
+			f.eof = true
+			close(f.stop)
+			return 0, io.EOF
+		}
+		if c.err != nil {
+			return 0, c.err
+		}
+		f.buf = c.data
+	}
+
+	n := copy(p, f.buf)
+	f.buf = f.buf[n:]
+	return n, nil
+}
+
+func (f *FollowReader) next() (chunk, bool) {
+	select {
+	case c, ok := <-f.chunks:
+		return c, ok
+	case <-f.ctx.Done():
+	}
+
+	// the pump may still have data, unless it is blocked reading from a
+	// terminal or a pipe that has nothing more to say
+	select {
+	case c, ok := <-f.chunks:
+		return c, ok
+	case <-time.After(f.interval):
+		return chunk{}, false
+	}
+}
+
+// pump reads r in the background, so that Read can give up on a blocking
+// read when ctx is done.
+func (f *FollowReader) pump(r io.Reader) {
+	defer close(f.chunks)
+	for {
+		buf := make([]byte, 32*1024)
+		n, err := r.Read(buf)
+		if n > 0 {
+			select {
+			case f.chunks <- chunk{data: buf[:n]}:
+			case <-f.stop:
+				return
+			}
+		}
+
+		switch {
+		case err == io.EOF:
+			if f.closed || f.ctx.Err() != nil {
+				return
+			}
+			// wait for new data, and read once more when cancelled to pick
+			// up lines written just before
+			select {
+			case <-f.ctx.Done():
+			case <-f.stop:
+				return
+			case <-time.After(f.interval):
+			}
+		case err != nil:
+			select {
+			case f.chunks <- chunk{err: err}:
+			case <-f.stop:
+			}
+			return
+		}
+	}
+}
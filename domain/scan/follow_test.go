@@ -0,0 +1,99 @@
+package scan_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+const pollInterval = 5 * time.Millisecond
+
+func TestFollowReader(t *testing.T) {
+	t.Run("reads lines appended after the end of file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "log.txt")
+		test.AssertNoError(t, os.WriteFile(path, []byte("first\n"), 0644))
+
+		file, err := os.Open(path)
+		test.AssertNoError(t, err)
+		defer file.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		lines := bufio.NewScanner(scan.NewFollowReader(ctx, file, pollInterval))
+
+		test.AssertTrue(t, lines.Scan())
+		test.AssertEqual(t, lines.Text(), "first")
+
+		appendTo(t, path, "sec")
+		time.Sleep(10 * pollInterval)
+		appendTo(t, path, "ond\n")
+		test.AssertTrue(t, lines.Scan())
+		test.AssertEqual(t, lines.Text(), "second")
+
+		appendTo(t, path, "third\n")
+		cancel()
+		test.AssertTrue(t, lines.Scan())
+		test.AssertEqual(t, lines.Text(), "third")
+		test.AssertFalse(t, lines.Scan())
+	})
+
+	t.Run("gives up a blocked read once cancelled", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		follow := scan.NewFollowReader(ctx, r, pollInterval)
+		cancel()
+
+		_, err := follow.Read(make([]byte, 1))
+		test.AssertError(t, err, io.EOF)
+	})
+
+	t.Run("ends when the writer closes a pipe", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		test.AssertNoError(t, err)
+		defer r.Close()
+
+		lines := bufio.NewScanner(scan.NewFollowReader(context.Background(), r, pollInterval))
+		_, err = io.WriteString(w, "first\n")
+		test.AssertNoError(t, err)
+		test.AssertNoError(t, w.Close())
+
+		test.AssertTrue(t, lines.Scan())
+		test.AssertEqual(t, lines.Text(), "first")
+		test.AssertFalse(t, lines.Scan())
+		test.AssertNoError(t, lines.Err())
+	})
+}
+
+func TestProcessorOnHeader(t *testing.T) {
+	var got scan.Header
+	p := &scan.Processor{OnHeader: func(h scan.Header) { got = h }}
+
+	_, err := p.ScanInputData(strings.NewReader("2\n09:00 19:00\n10\n"), io.Discard)
+	test.AssertNoError(t, err)
+	test.AssertEqual(t, got, scan.Header{
+		TablesCount: 2,
+		OpenTime:    store.NewDayTime(9, 0),
+		CloseTime:   store.NewDayTime(19, 0),
+		HourCost:    10,
+	})
+}
+
+func appendTo(t testing.TB, path, s string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	test.AssertNoError(t, err)
+	defer file.Close()
+	_, err = file.WriteString(s)
+	test.AssertNoError(t, err)
+}
@@ -0,0 +1,176 @@
+package scan_test
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+const testsDir = "../../tests"
+
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join(testsDir, "*.txt"))
+	test.AssertNoError(t, err)
+	if len(inputs) == 0 {
+		t.Fatalf("no input files found in %s", testsDir)
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			got := runInput(t, input)
+			golden := strings.TrimSuffix(input, ".txt") + ".golden"
+
+			if *update {
+				test.AssertNoError(t, os.WriteFile(golden, []byte(got), 0644))
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("can't read golden file, run 'go test ./domain/scan -update' to create it: %q", err)
+			}
+			if diff := test.LineDiff(string(want), got); diff != "" {
+				t.Fatalf("output differs from %s (-want +got):\n%s", golden, diff)
+			}
+		})
+	}
+}
+
+// runInput returns what cmd/main.go -corrections prints for the input file.
+func runInput(t testing.TB, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	test.AssertNoError(t, err)
+	defer file.Close()
+
+	buf := &strings.Builder{}
+	line, err := (&scan.Processor{Corrections: true}).ScanInputData(file, buf)
+	if err != nil {
+		return line + "\n"
+	}
+	return buf.String()
+}
+
+func TestJSONLinesRoundTrip(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join(testsDir, "*.txt"))
+	test.AssertNoError(t, err)
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".txt")
+		t.Run(name, func(t *testing.T) {
+			text, err := os.ReadFile(input)
+			test.AssertNoError(t, err)
+			jsonLines, ok := toJSONLines(string(text))
+			if !ok {
+				t.Skip("the input can't be written as JSON Lines")
+			}
+
+			textOut := &strings.Builder{}
+			_, textErr := (&scan.Processor{Corrections: true}).ScanInputData(strings.NewReader(string(text)), textOut)
+			jsonOut := &strings.Builder{}
+			_, jsonErr := (&scan.Processor{Corrections: true}).ScanInputData(strings.NewReader(jsonLines), jsonOut)
+
+			if textErr == nil {
+				test.AssertNoError(t, jsonErr)
+				if diff := test.LineDiff(textOut.String(), jsonOut.String()); diff != "" {
+					t.Fatalf("output differs (-text +jsonl):\n%s", diff)
+				}
+				return
+			}
+
+			var textPerr, jsonPerr *parse.ParseError
+			test.AssertTrue(t, errors.As(textErr, &textPerr))
+			test.AssertTrue(t, errors.As(jsonErr, &jsonPerr))
+			test.AssertError(t, jsonErr, textPerr.Err)
+			if textPerr.Line <= 3 {
+				// the header is a single line with its own field names
+				test.AssertEqual(t, jsonPerr.Line, 1)
+				return
+			}
+			test.AssertEqual(t, jsonPerr.Field, textPerr.Field)
+			test.AssertEqual(t, jsonPerr.Line+2, textPerr.Line)
+		})
+	}
+}
+
+// toJSONLines rewrites a text input as JSON Lines field by field, without
+// validating the values, so that both readers see the same mistakes.
+func toJSONLines(text string) (string, bool) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) < 3 {
+		return "", false
+	}
+
+	tables, err := strconv.Atoi(lines[0])
+	if err != nil {
+		return "", false
+	}
+	openTime, closeTime, ok := strings.Cut(lines[1], " ")
+	if !ok {
+		return "", false
+	}
+	hourCost, err := strconv.ParseFloat(lines[2], 64)
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	writeJSON(&b, map[string]any{"tables": tables, "open_time": openTime, "close_time": closeTime, "hour_cost": hourCost})
+
+	for _, line := range lines[3:] {
+		parts := strings.Split(strings.Trim(line, " "), " ")
+		if len(parts) < 3 {
+			return "", false
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return "", false
+		}
+		record := map[string]any{"time": parts[0], "id": id}
+		// corrections have the sequence number before the client
+		if id == event.VoidEventId || id == event.AmendEventId {
+			seq, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return "", false
+			}
+			record["seq"] = seq
+			parts = append(parts[:2], parts[3:]...)
+		}
+		if len(parts) > 4 {
+			return "", false
+		}
+		if len(parts) > 2 {
+			record["client"] = parts[2]
+		}
+		if len(parts) == 4 && id == event.PromotionEventId {
+			record["promotion"] = parts[3]
+		} else if len(parts) == 4 {
+			table, err := strconv.Atoi(parts[3])
+			if err != nil {
+				return "", false
+			}
+			record["table"] = table
+		}
+		writeJSON(&b, record)
+	}
+	return b.String(), true
+}
+
+func writeJSON(b *strings.Builder, v any) {
+	data, _ := json.Marshal(v)
+	b.Write(data)
+	b.WriteByte('\n')
+}
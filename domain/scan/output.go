@@ -0,0 +1,53 @@
+package scan
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// spillMemory is how much of the output spillBuffer keeps in memory.
+const spillMemory = 1 << 20
+
+// spillBuffer keeps the output of a run until the input turns out to be
+// correct. Past spillMemory bytes it is written to a temporary file, so that
+// a long input doesn't grow the heap.
+type spillBuffer struct {
+	mem  bytes.Buffer
+	file *os.File
+}
+
+func (b *spillBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.mem.Len()+len(p) <= spillMemory {
+		return b.mem.Write(p)
+	}
+	if b.file == nil {
+		file, err := os.CreateTemp("", "club-output-*")
+		if err != nil {
+			return 0, err
+		}
+		b.file = file
+	}
+	return b.file.Write(p)
+}
+
+func (b *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	n, err := b.mem.WriteTo(w)
+	if err != nil || b.file == nil {
+		return n, err
+	}
+	if _, err := b.file.Seek(0, io.SeekStart); err != nil {
+		return n, err
+	}
+	m, err := io.Copy(w, b.file)
+	return n + m, err
+}
+
+// Close removes the temporary file.
+func (b *spillBuffer) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
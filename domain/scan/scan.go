@@ -2,128 +2,580 @@ package scan
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"strings"
+	"time"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
 	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
 	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
 	"github.com/GerogeGol/yadro-test-problem/domain/service"
 	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/shift"
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
 	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
 )
 
+var UnknownErrorMode = fmt.Errorf("unknown error mode")
+
+// ErrorMode tells Process what to print when the input turns out to be
+// incorrect.
+type ErrorMode int
+
+const (
+	// DiscardOnError prints only the offending line, as the task requires.
+	// The output is held back until the end of the input, in a temporary
+	// file once it outgrows a megabyte. The subscribers still get what was
+	// served before the offending line.
+	DiscardOnError ErrorMode = iota
+	// FlushOnError prints every line as soon as it is processed and then the
+	// offending line.
+	FlushOnError
+)
+
+func ParseErrorMode(s string) (ErrorMode, error) {
+	switch s {
+	case "discard":
+		return DiscardOnError, nil
+	case "flush":
+		return FlushOnError, nil
+	}
+	return 0, fmt.Errorf("scan.ParseErrorMode: %q: %w", s, UnknownErrorMode)
+}
+
+var UnknownInputFormat = fmt.Errorf("unknown input format")
+
+// InputFormat is the syntax of the input lines.
+type InputFormat int
+
+const (
+	// DetectFormat picks JSONLines if the first line is a JSON object and
+	// Text otherwise.
+	DetectFormat InputFormat = iota
+	// Text is the space separated format of the task.
+	Text
+	// JSONLines has a JSON object per line: the header settings first and
+	// then the events.
+	JSONLines
+)
+
+func ParseInputFormat(s string) (InputFormat, error) {
+	switch s {
+	case "auto":
+		return DetectFormat, nil
+	case "text":
+		return Text, nil
+	case "jsonl":
+		return JSONLines, nil
+	}
+	return 0, fmt.Errorf("scan.ParseInputFormat: %q: %w", s, UnknownInputFormat)
+}
+
+var EventTimeIsBeforePrevious = fmt.Errorf("event time could not be before the time of the previous event")
+
 type FileScanner struct {
 	*bufio.Scanner
-	lastLine string
-	lastTime store.DayTime
+	Format     InputFormat
+	Parser     parse.Parser
+	lastLine   string
+	lineNumber int
+	lastTime   store.DayTime
 }
 
 func (s *FileScanner) Scan() bool {
 	scanRes := s.Scanner.Scan()
-	if !scanRes && s.Scanner.Err() != nil {
-		panic("Error while scanning file")
-	}
 	s.lastLine = s.Scanner.Text()
+	s.lineNumber++
+	if s.Format == DetectFormat && scanRes {
+		s.Format = Text
+		if parse.IsJSONLine(s.lastLine) {
+			s.Format = JSONLines
+		}
+	}
 	return scanRes
 }
 
-func (s *FileScanner) ScanTablesCount() (int, error) {
+// ScanJSONHeader parses the header of JSON Lines input.
+func (s *FileScanner) ScanJSONHeader() (Header, error) {
+	h, err := parse.JSONHeader(s.lastLine)
+	return Header{
+		TablesCount:   h.TablesCount,
+		OpenTime:      h.OpenTime,
+		CloseTime:     h.CloseTime,
+		HourCost:      h.HourCost,
+		QueueCapacity: h.QueueCapacity,
+		Location:      h.Location,
+	}, s.locate(err)
+}
 
-	return parse.TablesCount(s.lastLine)
+func (s *FileScanner) ScanTablesCount() (int, error) {
+	n, err := parse.TablesCount(s.lastLine)
+	return n, s.locate(err)
 }
 
 func (s *FileScanner) ScanClubWorkingTime() (openTime store.DayTime, closeTime store.DayTime, err error) {
-	return parse.ClubWorkingTime(s.lastLine)
+	openTime, closeTime, err = parse.ClubWorkingTime(s.lastLine)
+	return openTime, closeTime, s.locate(err)
 }
 
 func (s *FileScanner) ScanHourCost() (float64, error) {
-	return parse.HourCost(s.lastLine)
+	cost, err := parse.HourCost(s.lastLine)
+	return cost, s.locate(err)
 }
 
 func (s *FileScanner) ScanInputEvent() (e event.InputEvent, err error) {
-	e, err = parse.InputEvent(s.lastLine)
+	if s.Format == JSONLines {
+		e, err = s.Parser.JSONInputEvent(s.lastLine)
+	} else {
+		e, err = s.Parser.InputEvent(s.lastLine)
+	}
 	if err != nil {
+		err = s.locate(err)
 		return
 	}
 	if e.Time().Compare(s.lastTime.Time) == -1 {
-		err = fmt.Errorf("inconsisten time in events")
+		err = s.fieldError("time", 0, EventTimeIsBeforePrevious)
 		return
 	}
 	s.lastTime = e.Time()
 	return
 }
 
-func ScanInputData(r io.Reader, b io.Writer) (string, error) {
+// eventError attaches the current line to an error the club returned for
+// the event.
+func (s *FileScanner) eventError(e event.InputEvent, err error) error {
+	if _, ok := e.(*event.SitDownEvent); ok {
+		return s.fieldError("table", 3, err)
+	}
+	return s.fieldError("event", -1, err)
+}
+
+// locate sets the position of a parse error to the current line.
+func (s *FileScanner) locate(err error) error {
+	perr, ok := err.(*parse.ParseError)
+	if !ok {
+		return err
+	}
+	located := *perr
+	located.Line = s.lineNumber
+	if offset := strings.Index(s.lastLine, perr.Raw); offset > 0 {
+		located.Col += offset
+	}
+	located.Raw = s.lastLine
+	return &located
+}
+
+// fieldError returns a ParseError for the i-th token of the current event
+// line, or for the whole line if i is negative. In JSON Lines the field is
+// found by its key instead.
+func (s *FileScanner) fieldError(field string, i int, err error) error {
+	if s.Format == JSONLines {
+		return s.locate(parse.NewJSONError(s.lastLine, field, err))
+	}
+	return s.locate(parse.NewParseError(strings.Trim(s.lastLine, " "), field, i, err))
+}
+
+// Header is the club configuration from the first three input lines,
+// possibly overridden by a config file.
+type Header struct {
+	TablesCount int
+	OpenTime    store.DayTime
+	CloseTime   store.DayTime
+	HourCost    float64
+	// QueueCapacity is the number of clients allowed to wait, zero means the
+	// tables count.
+	QueueCapacity int
+	// Location is the time zone the club works in, nil means UTC, as the
+	// parser and the zero store.Clock take it.
+	// With a time zone the event times are put on the calendar, so that DST
+	// changes are billed by the real time passed, and a close time before
+	// the open time means the club closes the next day.
+	Location *time.Location
+	// Date is the day the club opens on, the zero value means today. It is
+	// only used with Location.
+	Date time.Time
+}
+
+// Clock returns the calendar of the club day, the zero Clock if the club has
+// no time zone.
+func (h Header) Clock() store.Clock {
+	return h.ClockAt(time.Now())
+}
+
+// ClockAt is Clock with the zero Date meaning the club day going on at now.
+// After midnight an overnight club is still on the day it opened until the
+// close time.
+func (h Header) ClockAt(now time.Time) store.Clock {
+	if h.Location == nil {
+		return store.Clock{}
+	}
+	overnight := h.CloseTime.Compare(h.OpenTime.Time) == -1
+	date := h.Date
+	if date.IsZero() {
+		date = now.In(h.Location)
+		if overnight && date.Hour()*60+date.Minute() <= h.CloseTime.Hour()*60+h.CloseTime.Minute() {
+			date = date.AddDate(0, 0, -1)
+		}
+	}
+	year, month, day := date.Date()
+	return store.Clock{
+		Day:       time.Date(year, month, day, 0, 0, 0, 0, h.Location),
+		Overnight: overnight,
+		NextDay:   h.CloseTime,
+	}
+}
+
+func (h Header) Zone() *time.Location {
+	if h.Location == nil {
+		return time.UTC
+	}
+	return h.Location
+}
+
+// Log is a fully read input file: the club header and all input events.
+type Log struct {
+	Header
+	Events []event.InputEvent
+}
+
+// ReadLog reads and validates the whole input without serving the events.
+// On error it returns the offending line.
+func ReadLog(r io.Reader) (log Log, line string, err error) {
 	scanner := &FileScanner{Scanner: bufio.NewScanner(r)}
 
-	scanner.Scan()
-	tablesCount, err := scanner.ScanTablesCount()
-	if err != nil {
-		return scanner.lastLine, err
+	if log.Header, err = new(Processor).scanHeader(scanner); err != nil {
+		return log, scanner.lastLine, err
 	}
-	b.Write([]byte(fmt.Sprintln(tablesCount)))
 
-	scanner.Scan()
-	openTime, closeTime, err := scanner.ScanClubWorkingTime()
-	if err != nil {
-		return scanner.lastLine, err
+	for scanner.Scan() {
+		e, err := scanner.ScanInputEvent()
+		if err != nil {
+			return log, scanner.lastLine, err
+		}
+		log.Events = append(log.Events, e)
 	}
-	b.Write([]byte(fmt.Sprintln(openTime, closeTime)))
+	if err := scanner.Err(); err != nil {
+		return log, scanner.lastLine, fmt.Errorf("scan.ReadLog: %w", err)
+	}
+	return log, "", nil
+}
 
-	scanner.Scan()
-	hourCost, err := scanner.ScanHourCost()
+// Processor serves input events and writes the output. The zero value is
+// ready to use.
+type Processor struct {
+	// OnHeader, if set, is called once the header has been read, before any
+	// event is served.
+	OnHeader func(h Header)
+	// Format, if set, renders the events generated by the club instead of
+	// their String method.
+	Format func(e event.Event) string
+	// Configure, if set, gets the header read from the input and returns the
+	// one to use instead.
+	Configure func(h Header) (Header, error)
+	// NoHeader tells that the input starts right with the events. Configure
+	// then gets the zero Header and has to fill it in.
+	NoHeader bool
+	// Input is the syntax of the input, detected from the first line by
+	// default.
+	Input InputFormat
+	// Parser checks the events, the zero value follows the task.
+	Parser parse.Parser
+	// Logger, if set, is given to the club and its store, and gets the
+	// header and incorrect input.
+	Logger *slog.Logger
+	// Events, if set, gets the events the club generates as they happen.
+	Events *bus.Bus[event.Event]
+	// DomainEvents, if set, gets the changes of the club state as they
+	// happen.
+	DomainEvents *bus.Bus[service.DomainEvent]
+	// Corrections makes the club serve the void and amend events. It keeps
+	// every event of the day for that, so they are rejected by default.
+	Corrections bool
+	// Shifts splits the club day among the operators, Handover, if set,
+	// gets the report of every shift as it ends.
+	Shifts   []shift.Shift
+	Handover func(r shift.Report)
+	// Tariffs prices the tables by their class and the time of day, the
+	// zero Plan bills every table at the hour cost of the header.
+	Tariffs tariff.Plan
+}
+
+func ScanInputData(r io.Reader, b io.Writer) (string, error) {
+	return new(Processor).ScanInputData(r, b)
+}
+
+// Process streams the result of processing r into w. On incorrect input it
+// writes the offending line according to mode and returns the error.
+func Process(r io.Reader, w io.Writer, mode ErrorMode) error {
+	return new(Processor).Process(r, w, mode)
+}
+
+func (p *Processor) ScanInputData(r io.Reader, b io.Writer) (string, error) {
+	scanner := &FileScanner{Scanner: bufio.NewScanner(r), Format: p.Input, Parser: p.Parser}
+
+	h, err := p.scanHeader(scanner)
 	if err != nil {
 		return scanner.lastLine, err
 	}
-	b.Write([]byte(fmt.Sprintln(hourCost)))
+	fmt.Fprintln(b, h.TablesCount)
+	fmt.Fprintln(b, h.OpenTime, h.CloseTime)
+	fmt.Fprintln(b, h.HourCost)
+
+	if p.OnHeader != nil {
+		p.OnHeader(h)
+	}
+
+	var storeOpts []memstore.Option
+	var clubOpts []service.Option
+	if p.Logger != nil {
+		p.Logger.Info("header read", "tables", h.TablesCount, "open_time", h.OpenTime, "close_time", h.CloseTime, "hour_cost", h.HourCost, "time_zone", h.Zone().String())
+		storeOpts = append(storeOpts, memstore.WithLogger(p.Logger))
+		clubOpts = append(clubOpts, service.WithLogger(p.Logger))
+	}
+	if p.DomainEvents != nil {
+		clubOpts = append(clubOpts, service.WithDomainEvents(p.DomainEvents))
+	}
+	cc := service.NewComputerClub(h.TablesCount, h.HourCost, h.OpenTime, h.CloseTime, memstore.NewStore(storeOpts...), memqueue.NewQueue(), clubOpts...)
+	if h.QueueCapacity > 0 {
+		cc.QueueCapacity = h.QueueCapacity
+	}
+	if cc.Tariffs, err = p.Tariffs.On(scanner.Parser.Clock); err != nil {
+		return "", fmt.Errorf("Processor.ScanInputData: %w", err)
+	}
+	var serviceOpts []service.ServiceOption
+	if p.Corrections {
+		serviceOpts = append(serviceOpts, service.WithReplay(func() (store.Store, queue.Queue) {
+			return memstore.NewStore(), memqueue.NewQueue()
+		}))
+	}
+	if p.Events != nil {
+		serviceOpts = append(serviceOpts, service.WithEvents(p.Events))
+	}
+	s := service.NewService(cc, serviceOpts...)
 
-	cc := service.NewComputerClub(tablesCount, hourCost, openTime, closeTime, memstore.NewStore(), memqueue.NewQueue())
-	s := service.NewService(cc)
+	if len(p.Shifts) > 0 {
+		ledger, err := p.ledger(h, scanner.Parser.Clock)
+		if err != nil {
+			return "", err
+		}
+		ledger.HourCost = cc.HourCost
+		defer cc.DomainEvents().Handle(ledger.Handle)()
+	}
 
-	b.Write([]byte(fmt.Sprintln(openTime)))
+	closed := false
+	closeClub := func() error {
+		closed = true
+		leaveEvents, err := s.Close()
+		if err != nil {
+			return fmt.Errorf("Processor.ScanInputData: %w", err)
+		}
+		for _, e := range leaveEvents {
+			fmt.Fprintln(b, p.format(&e))
+		}
+		return nil
+	}
+
+	fmt.Fprintln(b, h.OpenTime)
+	promotions := false
 	for scanner.Scan() {
 		e, err := scanner.ScanInputEvent()
 		if err != nil {
 			return scanner.lastLine, err
 		}
+		promotions = promotions || e.Id() == event.PromotionEventId
 
-		outEvent := s.ServeEvent(e)
-		b.Write([]byte(fmt.Sprintln(e)))
-		if event.IsEmpty(outEvent) {
-			continue
+		// clients can't stay past the close time, so the club is closed
+		// before the first later event
+		if !closed && e.Time().Compare(h.CloseTime.Time) == 1 {
+			if p.Logger != nil {
+				p.Logger.Info("event after the close time, closing the club", "time", e.Time(), "close_time", h.CloseTime)
+			}
+			if err := closeClub(); err != nil {
+				return scanner.lastLine, err
+			}
 		}
 
-		errEvent, ok := outEvent.(*event.ErrorEvent)
-		if !ok {
-			b.Write([]byte(fmt.Sprintln(outEvent)))
-			continue
+		outEvent := s.ServeEvent(e)
+		if errEvent, ok := outEvent.(*event.ErrorEvent); ok && !service.IsRecoverable(errEvent.Err()) {
+			return scanner.lastLine, scanner.eventError(e, errEvent.Err())
 		}
 
-		switch errEvent.Err() {
-		case service.ClientUnknown, service.PlaceIsBusy, service.NotOpenYet, service.ICanWaitNoLonger, service.YouShallNotPass:
-			b.Write([]byte(fmt.Sprintln(errEvent)))
-			continue
-		default:
-			return scanner.lastLine, errEvent.Err()
+		fmt.Fprintln(b, e)
+		if !event.IsEmpty(outEvent) {
+			fmt.Fprintln(b, p.format(outEvent))
 		}
 	}
-	leaveEvents, err := s.Close()
-	if err != nil {
-		panic(err)
+	if err := scanner.Err(); err != nil {
+		return scanner.lastLine, fmt.Errorf("Processor.ScanInputData: %w", err)
 	}
-	for _, e := range leaveEvents {
-		b.Write([]byte(fmt.Sprintln(&e)))
+	if !closed {
+		if err := closeClub(); err != nil {
+			return "", err
+		}
 	}
-	b.Write([]byte(fmt.Sprintln(closeTime)))
+	fmt.Fprintln(b, h.CloseTime)
 
 	tableInfos, err := s.Profit()
 	if err != nil {
-		panic(err)
+		return "", fmt.Errorf("Processor.ScanInputData: %w", err)
 	}
+	// the report of the task has no discounts, it is kept for the days
+	// without promotions
 	for _, info := range tableInfos {
-		b.Write([]byte(fmt.Sprintln(&info)))
+		if promotions {
+			fmt.Fprintln(b, info.Discounted())
+		} else {
+			fmt.Fprintln(b, &info)
+		}
 	}
 
 	return "", nil
 }
+
+// ledger returns the ledger of the shifts, with their starts put on the club
+// day.
+func (p *Processor) ledger(h Header, clock store.Clock) (*shift.Ledger, error) {
+	shifts := make([]shift.Shift, len(p.Shifts))
+	for i, s := range p.Shifts {
+		start, err := clock.At(s.Start)
+		if err != nil {
+			return nil, fmt.Errorf("Processor.ScanInputData: shift of %s: %w", s.Operator, err)
+		}
+		shifts[i] = shift.Shift{Start: start, Operator: s.Operator}
+	}
+	ledger := shift.NewLedger(h.OpenTime, h.HourCost, shifts)
+	ledger.Handover = p.Handover
+	return ledger, nil
+}
+
+func (p *Processor) scanHeader(scanner *FileScanner) (h Header, err error) {
+	// a club with a time zone may close after midnight, and the time zone
+	// may come from Configure, so the order of the working time in the
+	// header is checked after it
+	var overnight error
+	if !p.NoHeader {
+		scanner.Scan()
+		if scanner.Format == JSONLines {
+			h, err = scanner.ScanJSONHeader()
+		} else {
+			h, err = scanTextHeader(scanner)
+		}
+		if errors.Is(err, parse.OpenTimeIsAfterCloseTimeError) {
+			overnight, err = err, nil
+		}
+		if err != nil {
+			if scanErr := scanner.Err(); scanErr != nil {
+				err = fmt.Errorf("Processor.ScanInputData: %w", scanErr)
+			}
+			return
+		}
+	}
+
+	rejectOvernight := func() (Header, error) {
+		// the offending line is the working time, not the last one read
+		var perr *parse.ParseError
+		if errors.As(overnight, &perr) {
+			scanner.lastLine = perr.Raw
+		}
+		return h, overnight
+	}
+	if p.Configure != nil {
+		if h, err = p.Configure(h); err != nil {
+			if overnight != nil && errors.Is(err, parse.OpenTimeIsAfterCloseTimeError) {
+				return rejectOvernight()
+			}
+			return h, fmt.Errorf("Processor.ScanInputData: %w", err)
+		}
+	}
+	if overnight != nil && h.Location == nil && h.OpenTime.Compare(h.CloseTime.Time) == 1 {
+		return rejectOvernight()
+	}
+
+	if clock := h.Clock(); !clock.IsZero() {
+		if h.OpenTime, err = clock.At(h.OpenTime); err == nil {
+			h.CloseTime, err = clock.At(h.CloseTime)
+		}
+		if err != nil {
+			return h, fmt.Errorf("Processor.ScanInputData: %w", err)
+		}
+		scanner.Parser.Clock = clock
+	}
+	return h, nil
+}
+
+// scanTextHeader reads the three header lines, the first one has already
+// been scanned. A close time before the open time is returned with the
+// whole header, scanHeader decides whether it is an error.
+func scanTextHeader(scanner *FileScanner) (h Header, err error) {
+	if h.TablesCount, err = scanner.ScanTablesCount(); err != nil {
+		return
+	}
+
+	scanner.Scan()
+	h.OpenTime, h.CloseTime, err = scanner.ScanClubWorkingTime()
+	if err != nil && !errors.Is(err, parse.OpenTimeIsAfterCloseTimeError) {
+		return
+	}
+	overnight := err
+
+	scanner.Scan()
+	if h.HourCost, err = scanner.ScanHourCost(); err != nil {
+		return
+	}
+	return h, overnight
+}
+
+func (p *Processor) format(e event.Event) string {
+	if p.Format != nil {
+		return p.Format(e)
+	}
+	return fmt.Sprint(e)
+}
+
+func (p *Processor) Process(r io.Reader, w io.Writer, mode ErrorMode) error {
+	if mode == FlushOnError {
+		return p.process(r, w, w)
+	}
+
+	buf := &spillBuffer{}
+	defer buf.Close()
+	out := bufio.NewWriter(buf)
+	if err := p.process(r, out, w); err != nil {
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("scan.Process: %w", err)
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		return fmt.Errorf("scan.Process: %w", err)
+	}
+	return nil
+}
+
+// process writes the output to out, or the offending line to errOut.
+func (p *Processor) process(r io.Reader, out io.Writer, errOut io.Writer) error {
+	line, err := p.ScanInputData(r, out)
+	if err != nil {
+		p.logError(err)
+		fmt.Fprintln(errOut, line)
+		return err
+	}
+	return nil
+}
+
+func (p *Processor) logError(err error) {
+	if p.Logger == nil {
+		return
+	}
+	var perr *parse.ParseError
+	if errors.As(err, &perr) {
+		p.Logger.Error("incorrect input", "line", perr.Line, "field", perr.Field, "error", perr.Err)
+		return
+	}
+	p.Logger.Error("input could not be processed", "error", err)
+}
@@ -0,0 +1,567 @@
+package scan_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/generate"
+	"github.com/GerogeGol/yadro-test-problem/domain/parse"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/shift"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+const incorrectInput = `1
+09:00 19:00
+10
+09:00 1 client
+09:30 2 client 2
+10:00 4 client
+`
+
+func TestProcess(t *testing.T) {
+	t.Run("discard mode prints only the offending line", func(t *testing.T) {
+		buf := &strings.Builder{}
+		err := scan.Process(strings.NewReader(incorrectInput), buf, scan.DiscardOnError)
+		test.AssertNotNilError(t, err)
+		test.AssertEqual(t, buf.String(), "09:30 2 client 2\n")
+	})
+
+	t.Run("flush mode prints processed lines and the offending line", func(t *testing.T) {
+		buf := &strings.Builder{}
+		err := scan.Process(strings.NewReader(incorrectInput), buf, scan.FlushOnError)
+		test.AssertNotNilError(t, err)
+		test.AssertEqual(t, buf.String(), "1\n09:00 19:00\n10\n09:00\n09:00 1 client\n09:30 2 client 2\n")
+	})
+
+	t.Run("both modes print the same for correct input", func(t *testing.T) {
+		input := "1\n09:00 19:00\n10\n09:00 1 client\n10:00 4 client\n"
+		discarded, flushed := &strings.Builder{}, &strings.Builder{}
+
+		test.AssertNoError(t, scan.Process(strings.NewReader(input), discarded, scan.DiscardOnError))
+		test.AssertNoError(t, scan.Process(strings.NewReader(input), flushed, scan.FlushOnError))
+		test.AssertEqual(t, discarded.String(), flushed.String())
+	})
+
+	t.Run("input that can't be read twice", func(t *testing.T) {
+		pipe := struct{ io.Reader }{strings.NewReader(incorrectInput)}
+		buf := &strings.Builder{}
+		err := scan.Process(pipe, buf, scan.DiscardOnError)
+		test.AssertNotNilError(t, err)
+		test.AssertEqual(t, buf.String(), "09:30 2 client 2\n")
+	})
+
+	t.Run("output longer than kept in memory", func(t *testing.T) {
+		input := &strings.Builder{}
+		input.WriteString("1\n09:00 19:00\n10\n")
+		for i := 0; i < 40000; i++ {
+			fmt.Fprintf(input, "09:00 1 client%d\n", i)
+		}
+		discarded, flushed := &strings.Builder{}, &strings.Builder{}
+		test.AssertNoError(t, scan.Process(strings.NewReader(input.String()), discarded, scan.DiscardOnError))
+		test.AssertNoError(t, scan.Process(strings.NewReader(input.String()), flushed, scan.FlushOnError))
+		test.AssertTrue(t, discarded.Len() > 1<<20)
+		test.AssertEqual(t, discarded.String(), flushed.String())
+	})
+
+	t.Run("error carries the line", func(t *testing.T) {
+		err := scan.Process(strings.NewReader(incorrectInput), io.Discard, scan.FlushOnError)
+
+		var perr *parse.ParseError
+		test.AssertTrue(t, errors.As(err, &perr))
+		test.AssertEqual(t, perr.Line, 5)
+		test.AssertEqual(t, perr.Field, "table")
+		test.AssertEqual(t, perr.Col, 16)
+	})
+
+	t.Run("events out of order", func(t *testing.T) {
+		input := "1\n09:00 19:00\n10\n10:00 1 client\n  09:00 1 other\n"
+		err := scan.Process(strings.NewReader(input), io.Discard, scan.FlushOnError)
+		test.AssertError(t, err, scan.EventTimeIsBeforePrevious)
+
+		var perr *parse.ParseError
+		test.AssertTrue(t, errors.As(err, &perr))
+		test.AssertEqual(t, perr.Line, 5)
+		test.AssertEqual(t, perr.Col, 3)
+		test.AssertEqual(t, perr.Raw, "  09:00 1 other")
+	})
+
+	t.Run("club is closed before events after the close time", func(t *testing.T) {
+		input := "1\n09:00 19:00\n10\n09:00 1 client\n09:00 2 client 1\n20:00 4 client\n"
+		buf := &strings.Builder{}
+		err := scan.Process(strings.NewReader(input), buf, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+
+		want := "1\n09:00 19:00\n10\n09:00\n09:00 1 client\n09:00 2 client 1\n" +
+			"19:00 11 client\n20:00 4 client\n20:00 13 ClientUnknown\n19:00\n1 100 10:00\n"
+		test.AssertEqual(t, buf.String(), want)
+	})
+
+	t.Run("times with seconds", func(t *testing.T) {
+		input := "1\n09:00 19:00\n10\n09:00:00 1 client\n09:00:00 2 client 1\n2024-05-01T07:00:30-03:00 4 client\n"
+		buf := &strings.Builder{}
+		err := scan.Process(strings.NewReader(input), buf, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+
+		want := "1\n09:00 19:00\n10\n09:00\n09:00:00 1 client\n09:00:00 2 client 1\n10:00:30 4 client\n19:00\n1 20 01:00\n"
+		test.AssertEqual(t, buf.String(), want)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		readErr := errors.New("disk is gone")
+		r := io.MultiReader(strings.NewReader("1\n09:00 19:00\n10\n09:00 1 client\n"), iotest.ErrReader(readErr))
+		_, err := scan.ScanInputData(r, io.Discard)
+		test.AssertError(t, err, readErr)
+	})
+
+	t.Run("line too long", func(t *testing.T) {
+		input := "1\n09:00 19:00\n10\n09:00 1 " + strings.Repeat("a", bufio.MaxScanTokenSize) + "\n"
+		_, err := scan.ScanInputData(strings.NewReader(input), io.Discard)
+		test.AssertError(t, err, bufio.ErrTooLong)
+	})
+
+	t.Run("unknown error mode", func(t *testing.T) {
+		_, err := scan.ParseErrorMode("ignore")
+		test.AssertError(t, err, scan.UnknownErrorMode)
+	})
+}
+
+func TestProcessorConfigure(t *testing.T) {
+	t.Run("config overrides the header", func(t *testing.T) {
+		p := &scan.Processor{Configure: func(h scan.Header) (scan.Header, error) {
+			h.TablesCount = 2
+			return h, nil
+		}}
+		buf := &strings.Builder{}
+		err := p.Process(strings.NewReader(incorrectInput), buf, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasPrefix(buf.String(), "2\n09:00 19:00\n10\n"))
+	})
+
+	t.Run("input without header", func(t *testing.T) {
+		p := &scan.Processor{NoHeader: true, Configure: func(h scan.Header) (scan.Header, error) {
+			test.AssertEqual(t, h, scan.Header{})
+			return scan.Header{TablesCount: 1, OpenTime: store.NewDayTime(9, 0), CloseTime: store.NewDayTime(19, 0), HourCost: 10}, nil
+		}}
+		buf := &strings.Builder{}
+		err := p.Process(strings.NewReader("09:00 1 client\n"), buf, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, buf.String(), "1\n09:00 19:00\n10\n09:00\n09:00 1 client\n19:00 11 client\n19:00\n1 0 00:00\n")
+	})
+
+	t.Run("queue capacity", func(t *testing.T) {
+		p := &scan.Processor{Configure: func(h scan.Header) (scan.Header, error) {
+			h.QueueCapacity = 1
+			return h, nil
+		}}
+		input := "1\n09:00 19:00\n10\n09:00 1 a\n09:00 2 a 1\n09:01 1 b\n09:01 3 b\n09:02 1 c\n09:02 3 c\n"
+		buf := &strings.Builder{}
+		err := p.Process(strings.NewReader(input), buf, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.Contains(buf.String(), "09:02 3 c\n09:02 11 c\n"))
+	})
+
+	t.Run("club settings of the JSON header", func(t *testing.T) {
+		input := `{"tables": 1, "open_time": "09:00", "close_time": "19:00", "hour_cost": 10, "queue_capacity": 1}
+{"time": "09:00", "id": 1, "client": "a"}
+{"time": "09:00", "id": 2, "client": "a", "table": 1}
+{"time": "09:01", "id": 1, "client": "b"}
+{"time": "09:01", "id": 3, "client": "b"}
+{"time": "09:02", "id": 1, "client": "c"}
+{"time": "09:02", "id": 3, "client": "c"}
+`
+		var got scan.Header
+		p := &scan.Processor{Configure: func(h scan.Header) (scan.Header, error) {
+			got = h
+			return h, nil
+		}}
+		buf := &strings.Builder{}
+		err := p.Process(strings.NewReader(input), buf, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, got.QueueCapacity, 1)
+		test.AssertTrue(t, strings.Contains(buf.String(), "09:02 3 c\n09:02 11 c\n"))
+	})
+
+	t.Run("configure error", func(t *testing.T) {
+		p := &scan.Processor{Configure: func(h scan.Header) (scan.Header, error) {
+			return h, parse.LessOrEqualZeroError
+		}}
+		err := p.Process(strings.NewReader(incorrectInput), io.Discard, scan.DiscardOnError)
+		test.AssertError(t, err, parse.LessOrEqualZeroError)
+	})
+}
+
+func TestProcessorLogger(t *testing.T) {
+	buf := &strings.Builder{}
+	p := &scan.Processor{Logger: slog.New(slog.NewTextHandler(buf, nil))}
+
+	err := p.Process(strings.NewReader(incorrectInput), io.Discard, scan.DiscardOnError)
+	test.AssertNotNilError(t, err)
+
+	logged := buf.String()
+	test.AssertEqual(t, strings.Count(logged, "msg=\"incorrect input\""), 1)
+	test.AssertTrue(t, strings.Contains(logged, "line=5 field=table"))
+	// the input is served in one pass, the club has started by the error
+	test.AssertTrue(t, strings.Contains(logged, "header read"))
+}
+
+func TestProcessorDomainEvents(t *testing.T) {
+	file, err := os.Open("../../tests/basic.txt")
+	test.AssertNoError(t, err)
+	defer file.Close()
+
+	events := bus.New[service.DomainEvent]()
+	var billed float64
+	var closed int
+	events.Handle(func(e service.DomainEvent) {
+		switch e := e.(type) {
+		case service.SessionBilled:
+			billed += e.Payment
+		case service.ClubClosed:
+			closed++
+		}
+	})
+
+	p := &scan.Processor{DomainEvents: events}
+	test.AssertNoError(t, p.Process(file, io.Discard, scan.DiscardOnError))
+	test.AssertEqual(t, closed, 1)
+	test.AssertEqual(t, billed, 190.0)
+}
+
+func TestPromotionsReport(t *testing.T) {
+	t.Run("a day with promotions prints the discounts, none taken", func(t *testing.T) {
+		input := `1
+09:00 19:00
+10
+09:00 1 a
+09:00 7 a points
+09:00 2 a 1
+10:30 4 a
+`
+		buf := &strings.Builder{}
+		_, err := scan.ScanInputData(strings.NewReader(input), buf)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasSuffix(buf.String(), "\n19:00\n1 20 01:30 20 0\n"))
+	})
+
+	t.Run("a day without them prints the report of the task", func(t *testing.T) {
+		input := `1
+09:00 19:00
+10
+09:00 1 a
+09:00 2 a 1
+10:30 4 a
+`
+		buf := &strings.Builder{}
+		_, err := scan.ScanInputData(strings.NewReader(input), buf)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasSuffix(buf.String(), "\n19:00\n1 20 01:30\n"))
+	})
+}
+
+func TestProcessorShifts(t *testing.T) {
+	file, err := os.Open("../../tests/basic.txt")
+	test.AssertNoError(t, err)
+	defer file.Close()
+
+	var reports []shift.Report
+	p := &scan.Processor{
+		Shifts: []shift.Shift{
+			{Start: store.NewDayTime(9, 0), Operator: "anna"},
+			{Start: store.NewDayTime(16, 0), Operator: "boris"},
+		},
+		Handover: func(r shift.Report) { reports = append(reports, r) },
+	}
+	test.AssertNoError(t, p.Process(file, io.Discard, scan.DiscardOnError))
+	test.AssertEqual(t, len(reports), 2)
+	test.AssertEqual(t, reports[0].Billed, 100.0)
+	test.AssertEqual(t, reports[1].Billed, 90.0)
+	test.AssertEqual(t, len(reports[0].Open), 1)
+	test.AssertEqual(t, reports[0].Open[0].Client, "client3")
+	test.AssertTrue(t, math.Abs(reports[0].Revenue+reports[1].Revenue-190) < 1e-9)
+}
+
+func TestProcessorTariffs(t *testing.T) {
+	var reports []shift.Report
+	p := &scan.Processor{
+		Tariffs: tariff.Plan{
+			Classes: []tariff.Class{{Name: "vip", Tables: []int{2}, HourCost: 20}},
+			Tariffs: []tariff.Tariff{{From: store.NewDayTime(12, 0), HourCost: 15}},
+		},
+		Shifts: []shift.Shift{
+			{Start: store.NewDayTime(9, 0), Operator: "anna"},
+			{Start: store.NewDayTime(12, 30), Operator: "boris"},
+		},
+		Handover: func(r shift.Report) { reports = append(reports, r) },
+	}
+	input := "2\n09:00 19:00\n10\n" +
+		"09:00 1 a\n09:00 2 a 1\n09:00 1 b\n09:00 2 b 2\n" +
+		"10:30 4 b\n11:00 4 a\n" +
+		"12:00 1 c\n12:00 2 c 1\n12:30 1 d\n13:00 4 c\n"
+	out := &strings.Builder{}
+	test.AssertNoError(t, p.Process(strings.NewReader(input), out, scan.DiscardOnError))
+	test.AssertTrue(t, strings.HasSuffix(out.String(), "\n1 35 03:00\n2 40 01:30\n"))
+
+	test.AssertEqual(t, len(reports), 2)
+	test.AssertEqual(t, reports[0].Open[0].Accrued, 7.5)
+	test.AssertEqual(t, reports[0].Revenue+reports[1].Revenue, 75.0)
+}
+
+func TestHeaderClock(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	test.AssertNoError(t, err)
+	at := func(day, hour int) time.Time { return time.Date(2024, 5, day, hour, 0, 0, 0, berlin) }
+	overnight := scan.Header{OpenTime: store.NewDayTime(22, 0), CloseTime: store.NewDayTime(6, 0), Location: berlin}
+
+	t.Run("overnight club before midnight", func(t *testing.T) {
+		test.AssertEqual(t, overnight.ClockAt(at(2, 23)).Day, at(2, 0))
+	})
+	t.Run("overnight club after midnight is on the day it opened", func(t *testing.T) {
+		clock := overnight.ClockAt(at(2, 3))
+		test.AssertEqual(t, clock.Day, at(1, 0))
+		closeAt, err := clock.At(overnight.CloseTime)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, closeAt.Time.Equal(at(2, 6)))
+	})
+	t.Run("overnight club after the close time", func(t *testing.T) {
+		test.AssertEqual(t, overnight.ClockAt(at(2, 7)).Day, at(2, 0))
+	})
+	t.Run("day club", func(t *testing.T) {
+		h := scan.Header{OpenTime: store.NewDayTime(9, 0), CloseTime: store.NewDayTime(19, 0), Location: berlin}
+		test.AssertEqual(t, h.ClockAt(at(2, 3)).Day, at(2, 0))
+	})
+	t.Run("date is kept", func(t *testing.T) {
+		h := overnight
+		h.Date = at(10, 0)
+		test.AssertEqual(t, h.ClockAt(at(2, 3)).Day, at(10, 0))
+	})
+	t.Run("no time zone", func(t *testing.T) {
+		h := overnight
+		h.Location = nil
+		test.AssertTrue(t, h.ClockAt(at(2, 3)).IsZero())
+	})
+}
+
+func TestProcessorTimeZone(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	test.AssertNoError(t, err)
+
+	process := func(t *testing.T, date string, input string) (string, error) {
+		t.Helper()
+		day, err := time.Parse(time.DateOnly, date)
+		test.AssertNoError(t, err)
+		p := &scan.Processor{Configure: func(h scan.Header) (scan.Header, error) {
+			h.Location, h.Date = berlin, day
+			return h, nil
+		}}
+		buf := &strings.Builder{}
+		_, err = p.ScanInputData(strings.NewReader(input), buf)
+		return buf.String(), err
+	}
+
+	t.Run("spring forward day is 23 hours long", func(t *testing.T) {
+		out, err := process(t, "2024-03-31", "1\n00:00 23:59\n10\n01:30 1 a\n01:30 2 a 1\n03:30 4 a\n")
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasSuffix(out, "23:59\n1 10 01:00\n"))
+	})
+
+	t.Run("fall back day is 25 hours long", func(t *testing.T) {
+		out, err := process(t, "2024-10-27", "1\n00:00 23:59\n10\n01:30 1 a\n01:30 2 a 1\n03:30 4 a\n")
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasSuffix(out, "23:59\n1 30 03:00\n"))
+	})
+
+	t.Run("working time over 24 hours", func(t *testing.T) {
+		out, err := process(t, "2024-10-27", "1\n00:00 23:59\n10\n00:00 1 a\n00:00 2 a 1\n")
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasSuffix(out, "23:59 11 a\n23:59\n1 250 24:59\n"))
+	})
+
+	t.Run("skipped time", func(t *testing.T) {
+		_, err := process(t, "2024-03-31", "1\n00:00 23:59\n10\n02:30 1 a\n")
+		test.AssertError(t, err, store.NonexistentTime)
+		var perr *parse.ParseError
+		test.AssertTrue(t, errors.As(err, &perr))
+		test.AssertEqual(t, perr.Line, 4)
+		test.AssertEqual(t, perr.Field, "time")
+	})
+
+	t.Run("timestamps tell apart the repeated hour", func(t *testing.T) {
+		input := "1\n00:00 23:59\n10\n" +
+			"2024-10-27T02:30:00+02:00 1 a\n" +
+			"2024-10-27T02:30:00+02:00 2 a 1\n" +
+			"2024-10-27T02:10:00+01:00 4 a\n"
+		out, err := process(t, "2024-10-27", input)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.Contains(out, "02:30:00 1 a\n02:30:00 2 a 1\n02:10:00 4 a\n"))
+		test.AssertTrue(t, strings.HasSuffix(out, "1 10 00:40\n"))
+	})
+
+	t.Run("overnight club", func(t *testing.T) {
+		p := &scan.Processor{NoHeader: true, Configure: func(scan.Header) (scan.Header, error) {
+			return scan.Header{
+				TablesCount: 1,
+				OpenTime:    store.NewDayTime(22, 0),
+				CloseTime:   store.NewDayTime(6, 0),
+				HourCost:    10,
+				Location:    berlin,
+				Date:        time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+			}, nil
+		}}
+		buf := &strings.Builder{}
+		_, err := p.ScanInputData(strings.NewReader("23:00 1 a\n23:00 2 a 1\n01:00 1 b\n04:00 4 a\n"), buf)
+		test.AssertNoError(t, err)
+		// the clocks go from 02:00 to 03:00 during the night
+		test.AssertTrue(t, strings.HasSuffix(buf.String(), "04:00 4 a\n06:00 11 b\n06:00\n1 40 04:00\n"))
+	})
+
+	t.Run("overnight header", func(t *testing.T) {
+		out, err := process(t, "2024-03-30", "1\n22:00 06:00\n10\n23:00 1 a\n23:00 2 a 1\n2024-03-31T03:00:00+02:00 4 a\n")
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.HasSuffix(out, "03:00:00 4 a\n06:00\n1 30 03:00\n"))
+	})
+
+	t.Run("overnight header without a time zone", func(t *testing.T) {
+		p := &scan.Processor{Configure: func(h scan.Header) (scan.Header, error) {
+			return h, nil
+		}}
+		line, err := p.ScanInputData(strings.NewReader("1\n22:00 06:00\n10\n"), &strings.Builder{})
+		test.AssertError(t, err, parse.OpenTimeIsAfterCloseTimeError)
+		test.AssertEqual(t, line, "22:00 06:00")
+	})
+
+	t.Run("timestamp on another day", func(t *testing.T) {
+		_, err := process(t, "2024-03-30", "1\n22:00 06:00\n10\n2024-03-31T23:00:00+02:00 1 a\n")
+		test.AssertError(t, err, store.OtherDay)
+	})
+
+	t.Run("no time zone means UTC", func(t *testing.T) {
+		var header scan.Header
+		p := &scan.Processor{OnHeader: func(h scan.Header) { header = h }}
+		out := &strings.Builder{}
+		err := p.Process(strings.NewReader("1\n05:00 19:00\n10\n2024-05-01T09:00:00+03:00 1 a\n"), out, scan.DiscardOnError)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, strings.Contains(out.String(), "\n06:00:00 1 a\n"))
+		test.AssertEqual(t, header.Zone(), time.UTC)
+		test.AssertEqual(t, header.Clock().Location(), time.UTC)
+	})
+}
+
+func FuzzScanInputData(f *testing.F) {
+	f.Add(incorrectInput)
+	f.Add("1\n09:00 19:00\n10\n20:00 1 client\n")
+	f.Add("{\"tables\": 1, \"open_time\": \"09:00\", \"close_time\": \"19:00\", \"hour_cost\": 10}\n{\"time\": \"09:00\", \"id\": 1, \"client\": \"a\"}\n")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		if log, _, err := scan.ReadLog(strings.NewReader(input)); err == nil && log.TablesCount > 1000 {
+			t.Skip("every table is printed, which takes too long for fuzzing")
+		}
+
+		buf := &strings.Builder{}
+		if _, err := scan.ScanInputData(strings.NewReader(input), buf); err != nil {
+			return
+		}
+
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		tables, err := strconv.Atoi(lines[0])
+		test.AssertNoError(t, err)
+		closeTime := strings.Split(lines[1], " ")[1]
+		// the close time is printed right before the tables
+		footer := len(lines) - tables - 1
+		if footer < 4 || lines[footer] != closeTime {
+			t.Fatalf("no close time before the %d tables in:\n%s", tables, buf)
+		}
+		var last string
+		for _, line := range lines[4:footer] {
+			at, _, _ := strings.Cut(line, " ")
+			if at < last {
+				t.Fatalf("event %q is before the previous one at %s in:\n%s", line, last, buf)
+			}
+			last = at
+		}
+	})
+}
+
+// BenchmarkProcess runs a generated log of about a million events. The
+// peak-heap-MB metric stays flat while the input grows, because nothing but
+// the club state is kept in memory.
+func BenchmarkProcess(b *testing.B) {
+	path := filepath.Join(b.TempDir(), "big.txt")
+	file, err := os.Create(path)
+	test.AssertNoError(b, err)
+
+	cfg := generate.DefaultConfig()
+	cfg.Tables = 500
+	cfg.ArrivalRate = 40000
+	cfg.SessionMean = 10 * time.Minute
+	cfg.ErrorRate = 0.05
+	w := bufio.NewWriter(file)
+	test.AssertNoError(b, generate.Generate(w, cfg))
+	test.AssertNoError(b, w.Flush())
+
+	info, err := file.Stat()
+	test.AssertNoError(b, err)
+	file.Close()
+
+	b.ReportAllocs()
+	b.SetBytes(info.Size())
+	b.ResetTimer()
+
+	var peak atomic.Uint64
+	for i := 0; i < b.N; i++ {
+		stop := samplePeakHeap(&peak)
+
+		file, err := os.Open(path)
+		test.AssertNoError(b, err)
+		test.AssertNoError(b, scan.Process(file, io.Discard, scan.DiscardOnError))
+		file.Close()
+
+		stop()
+	}
+	b.ReportMetric(float64(info.Size())/(1<<20), "input-MB")
+	b.ReportMetric(float64(peak.Load())/(1<<20), "peak-heap-MB")
+}
+
+func samplePeakHeap(peak *atomic.Uint64) (stop func()) {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		var stats runtime.MemStats
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapInuse > peak.Load() {
+				peak.Store(stats.HeapInuse)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
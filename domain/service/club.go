@@ -1,42 +1,75 @@
 package service
 
 import (
-	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/logging"
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 	"github.com/GerogeGol/yadro-test-problem/domain/queue"
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
 )
 
-var YouShallNotPass = errors.New("YouShallNotPass")
-var NotOpenYet = errors.New("NotOpenYet")
-var PlaceIsBusy = errors.New("PlaceIsBusy")
-var ClientUnknown = errors.New("ClientUnknown")
-var ICanWaitNoLonger = errors.New("ICanWaitNoLonger!")
-
 type ComputerClub struct {
 	ComputerCount int
 	busyComputers int
+	// QueueCapacity is the number of clients allowed to wait for a table.
+	// NewComputerClub sets it to ComputerCount.
+	QueueCapacity int
 	MoneyPerHour  float64
-	OpenTime      store.DayTime
-	CloseTime     store.DayTime
-	store         store.Store
-	queue         queue.Queue
+	// Tariffs prices the tables by their class and the time of day, the
+	// zero Plan bills every table at MoneyPerHour.
+	Tariffs tariff.Plan
+	// Loyalty is how the clients earn and spend points, NewComputerClub
+	// sets it to promo.DefaultLoyalty.
+	Loyalty      promo.Loyalty
+	OpenTime     store.DayTime
+	CloseTime    store.DayTime
+	store        store.Store
+	queue        queue.Queue
+	logger       *slog.Logger
+	domainEvents *bus.Bus[DomainEvent]
+	// reserved maps a table held by the staff to the client it is held for.
+	reserved map[int]string
+}
+
+// Option configures a ComputerClub.
+type Option func(*ComputerClub)
+
+// WithLogger makes the club log why it answers events the way it does.
+func WithLogger(logger *slog.Logger) Option {
+	return func(cc *ComputerClub) {
+		cc.logger = logger
+	}
 }
 
-func NewComputerClub(computerCount int, moneyPerHour float64, openTime store.DayTime, closeTime store.DayTime, store store.Store, queue queue.Queue) *ComputerClub {
-	return &ComputerClub{
+func NewComputerClub(computerCount int, moneyPerHour float64, openTime store.DayTime, closeTime store.DayTime, store store.Store, queue queue.Queue, opts ...Option) *ComputerClub {
+	cc := &ComputerClub{
 		ComputerCount: computerCount,
+		QueueCapacity: computerCount,
 		OpenTime:      openTime,
 		CloseTime:     closeTime,
 		MoneyPerHour:  moneyPerHour,
+		Loyalty:       promo.DefaultLoyalty,
 		store:         store,
 		queue:         queue,
+		logger:        logging.Discard(),
+		domainEvents:  bus.New[DomainEvent](),
+		reserved:      map[int]string{},
+	}
+	for _, opt := range opts {
+		opt(cc)
 	}
+	return cc
 }
 
 func (cc *ComputerClub) Arrive(t store.DayTime, client string) error {
 	if t.Compare(cc.OpenTime.Time) == -1 || t.Compare(cc.CloseTime.Time) >= 0 {
+		cc.logger.Info("arrival outside working hours", "client", client, "open_time", cc.OpenTime, "close_time", cc.CloseTime)
 		return NotOpenYet
 	}
 
@@ -46,16 +79,21 @@ func (cc *ComputerClub) Arrive(t store.DayTime, client string) error {
 	}
 
 	if exists {
+		cc.logger.Info("client is in the club already", "client", client)
 		return YouShallNotPass
 	}
 
 	cc.store.AddClient(client)
+	cc.logger.Info("client arrived", "client", client)
+	if cc.domainEvents.Listened() {
+		cc.domainEvents.Publish(ClientArrived{Time: t, Client: client})
+	}
 	return nil
 }
 
 func (cc *ComputerClub) SitDown(t store.DayTime, clientName string, tableNumber int) error {
 	if tableNumber <= 0 || tableNumber > cc.ComputerCount {
-		return fmt.Errorf("ComputerClub.SitDown: incorrect tableNumber")
+		return IncorrectTableNumber
 	}
 
 	exists, err := cc.store.IsClientExists(clientName)
@@ -72,6 +110,11 @@ func (cc *ComputerClub) SitDown(t store.DayTime, clientName string, tableNumber
 	}
 
 	if isBusy {
+		cc.logger.Info("table is taken", "client", clientName, "table", tableNumber)
+		return PlaceIsBusy
+	}
+	if holder, ok := cc.reserved[tableNumber]; ok && holder != clientName {
+		cc.logger.Info("table is reserved", "client", clientName, "table", tableNumber, "reserved_for", holder)
 		return PlaceIsBusy
 	}
 
@@ -80,11 +123,19 @@ func (cc *ComputerClub) SitDown(t store.DayTime, clientName string, tableNumber
 		return fmt.Errorf("ComputerClub.SitDown: %w", err)
 	}
 
+	delete(cc.reserved, tableNumber)
 	if client.Table == 0 {
 		cc.setClientTable(t, clientName, tableNumber)
-
+		cc.logger.Info("client sat down", "client", clientName, "table", tableNumber)
+		if cc.domainEvents.Listened() {
+			cc.domainEvents.Publish(ClientSeated{Time: t, Client: clientName, Table: tableNumber})
+		}
 	} else {
 		cc.changeClientTable(t, clientName, tableNumber)
+		cc.logger.Info("client changed table", "client", clientName, "table", tableNumber, "previous_table", client.Table)
+		if cc.domainEvents.Listened() {
+			cc.domainEvents.Publish(TableChanged{Time: t, Client: clientName, Previous: client.Table, Table: tableNumber})
+		}
 	}
 	return nil
 }
@@ -98,15 +149,41 @@ func (cc *ComputerClub) Wait(t store.DayTime, clientName string) (bool, error) {
 		return false, ClientUnknown
 	}
 
-	if cc.queue.Len() >= cc.ComputerCount {
+	client, err := cc.store.Client(clientName)
+	if err != nil {
+		return false, fmt.Errorf("ComputerClub.Wait: %w", err)
+	}
+
+	// a client seated or waiting already takes no place in the queue, so a
+	// full queue doesn't send it away
+	stays := client.Table != 0 || cc.queue.Contains(clientName)
+
+	if !stays && cc.queue.Len() >= cc.QueueCapacity {
+		cc.logger.Info("queue is full, client leaves", "client", clientName, "queue_length", cc.queue.Len(), "queue_capacity", cc.QueueCapacity)
+		if err := cc.store.RemoveClient(clientName); err != nil {
+			return false, fmt.Errorf("ComputerClub.Wait: %w", err)
+		}
+		if cc.domainEvents.Listened() {
+			cc.domainEvents.Publish(ClientLeft{Time: t, Client: clientName})
+		}
 		return false, nil
 	}
 
-	if cc.busyComputers < cc.ComputerCount {
+	if cc.busyComputers+len(cc.reserved) < cc.ComputerCount {
+		cc.logger.Info("a table is free, no need to wait", "client", clientName, "busy_tables", cc.busyComputers, "reserved_tables", len(cc.reserved))
 		return false, ICanWaitNoLonger
 	}
 
+	if stays {
+		cc.logger.Info("client has a table or waits already", "client", clientName, "table", client.Table)
+		return true, nil
+	}
+
 	cc.queue.Push(clientName)
+	cc.logger.Info("client waits", "client", clientName, "queue_length", cc.queue.Len())
+	if cc.domainEvents.Listened() {
+		cc.domainEvents.Publish(ClientQueued{Time: t, Client: clientName, Position: cc.queue.Len()})
+	}
 	return true, nil
 }
 
@@ -127,30 +204,109 @@ func (cc *ComputerClub) Leave(t store.DayTime, clientName string) (seatedClient
 		return
 	}
 
-	if err = cc.clientLeave(t, client); err != nil {
+	billed, err := cc.clientLeave(t, client)
+	if err != nil {
 		return
 	}
 
-	if cc.queue.Len() == 0 {
-		cc.busyComputers--
+	if client.Table == 0 {
+		if cc.queue.Remove(clientName) {
+			cc.logger.Info("client left the queue", "client", clientName)
+		} else {
+			cc.logger.Info("client left", "client", clientName)
+		}
+		if cc.domainEvents.Listened() {
+			cc.domainEvents.Publish(ClientLeft{Time: t, Client: clientName})
+		}
 		return
 	}
 
-	waitClient, _ := cc.queue.Top()
-	if err = cc.queue.Pop(); err != nil {
-		return
+	cc.busyComputers--
+	if cc.queue.Len() != 0 {
+		waitClient, _ := cc.queue.Top()
+		if err = cc.queue.Pop(); err != nil {
+			return
+		}
+		if err = cc.setClientTable(t, waitClient, client.Table); err != nil {
+			return
+		}
+		seatedClient.Name = waitClient
+		seatedClient.Table = client.Table
+		occupied = true
+		cc.logger.Info("client promoted from the queue", "client", waitClient, "table", client.Table, "queue_length", cc.queue.Len())
+	}
+
+	// the handlers see the club after the table is given to the queue
+	if cc.domainEvents.Listened() {
+		cc.domainEvents.Publish(billed)
+		cc.domainEvents.Publish(ClientLeft{Time: t, Client: clientName, Table: client.Table})
+		if occupied {
+			cc.domainEvents.Publish(ClientSeated{Time: t, Client: seatedClient.Name, Table: seatedClient.Table, FromQueue: true})
+		}
 	}
+	return seatedClient, occupied, nil
+}
 
-	if err = cc.setClientTable(t, waitClient, client.Table); err != nil {
-		return
+// Promote attaches a promotion to a client in the club, in place of the one
+// attached before. It is applied when the client pays.
+func (cc *ComputerClub) Promote(t store.DayTime, clientName string, promotion string) error {
+	parsed, err := promo.Parse(promotion)
+	if err != nil {
+		return fmt.Errorf("ComputerClub.Promote: %w", err)
+	}
+	exists, err := cc.store.IsClientExists(clientName)
+	if err != nil {
+		return fmt.Errorf("ComputerClub.Promote: %w", err)
+	}
+	if !exists {
+		return ClientUnknown
+	}
+
+	if err := cc.store.UpdateClientPromotion(clientName, parsed); err != nil {
+		return fmt.Errorf("ComputerClub.Promote: %w", err)
+	}
+	cc.logger.Info("promotion attached", "client", clientName, "promotion", promotion)
+	if cc.domainEvents.Listened() {
+		cc.domainEvents.Publish(PromotionAttached{Time: t, Client: clientName, Promotion: promotion})
+	}
+	return nil
+}
+
+// Reserve holds a free table for a client: nobody else can sit down at it
+// and waiting clients don't count it as free, until the client sits down
+// or the reservation is cancelled.
+func (cc *ComputerClub) Reserve(tableNumber int, clientName string) error {
+	if tableNumber <= 0 || tableNumber > cc.ComputerCount {
+		return IncorrectTableNumber
+	}
+	isBusy, err := cc.store.IsTableBusy(tableNumber)
+	if err != nil {
+		return fmt.Errorf("ComputerClub.Reserve: %w", err)
+	}
+	if _, ok := cc.reserved[tableNumber]; ok || isBusy {
+		return PlaceIsBusy
+	}
+	cc.reserved[tableNumber] = clientName
+	cc.logger.Info("table reserved", "client", clientName, "table", tableNumber)
+	return nil
+}
+
+// CancelReservation frees a table held by Reserve.
+func (cc *ComputerClub) CancelReservation(tableNumber int) {
+	if _, ok := cc.reserved[tableNumber]; ok {
+		delete(cc.reserved, tableNumber)
+		cc.logger.Info("reservation cancelled", "table", tableNumber)
 	}
+}
 
-	seatedClient.Name = waitClient
-	seatedClient.Table = client.Table
-	return seatedClient, true, nil
+// Reservation returns the client a table is held for.
+func (cc *ComputerClub) Reservation(tableNumber int) (clientName string, ok bool) {
+	clientName, ok = cc.reserved[tableNumber]
+	return
 }
 
 func (cc *ComputerClub) Close() ([]store.Client, error) {
+	clear(cc.reserved)
 	var leavedClients []store.Client
 	for cc.queue.Len() != 0 {
 		name, _ := cc.queue.Top()
@@ -170,15 +326,41 @@ func (cc *ComputerClub) Close() ([]store.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	// the store keeps no order, the clients are sent away by name
+	slices.SortFunc(clients, func(a, b store.Client) int {
+		return strings.Compare(a.Name, b.Name)
+	})
 
 	for _, client := range clients {
 		cc.Leave(cc.CloseTime, client.Name)
 		leavedClients = append(leavedClients, client)
 	}
 
+	cc.logger.Info("club closed", "clients_sent_away", len(leavedClients))
+	sentAway := make([]string, len(leavedClients))
+	for i, client := range leavedClients {
+		sentAway[i] = client.Name
+	}
+	cc.domainEvents.Publish(ClubClosed{Time: cc.CloseTime, SentAway: sentAway})
 	return leavedClients, nil
 }
 
+// BusyComputers returns the number of tables the club counts as taken.
+func (cc *ComputerClub) BusyComputers() int {
+	return cc.busyComputers
+}
+
+// Clients returns the clients in the club, seated or not.
+func (cc *ComputerClub) Clients() ([]store.Client, error) {
+	return cc.store.Clients()
+}
+
+// Waiting returns the clients in the queue, the next one to get a table
+// first.
+func (cc *ComputerClub) Waiting() []string {
+	return cc.queue.Values()
+}
+
 func (cc *ComputerClub) Info(tableNumber int) (store.Table, error) {
 	return cc.store.Table(tableNumber)
 }
@@ -190,7 +372,7 @@ func (cc *ComputerClub) TablesInfo() ([]TableInfo, error) {
 		if err != nil {
 			return nil, err
 		}
-		tableInfo := TableInfo{Number: i, WorkingTime: table.WorkingTime, Profit: table.Profit}
+		tableInfo := TableInfo{Number: i, WorkingTime: table.WorkingTime, Profit: table.Profit, Discount: table.Discount}
 		tables = append(tables, tableInfo)
 	}
 	return tables, nil
@@ -234,31 +416,87 @@ func (cc *ComputerClub) changeClientTable(t store.DayTime, clientName string, ta
 	return nil
 }
 
-func (cc *ComputerClub) clientLeave(t store.DayTime, client store.Client) error {
+// clientLeave removes the client from the club and bills the session of a
+// seated client, the table is then free in the store. The returned
+// SessionBilled is for Leave to publish once it is done.
+func (cc *ComputerClub) clientLeave(t store.DayTime, client store.Client) (SessionBilled, error) {
 	if err := cc.store.RemoveClient(client.Name); err != nil {
-		return err
+		return SessionBilled{}, err
 	}
 
 	if client.Table == 0 {
-		return nil
+		return SessionBilled{}, nil
 	}
 
 	table, err := cc.store.Table(client.Table)
 	if err != nil {
-		return err
+		return SessionBilled{}, err
 	}
 
 	playingTime := client.PlayingTime(t)
-	payment := client.Payment(t, cc.MoneyPerHour)
+	hourCost := cc.HourCost(client.Table, client.PlayingSince)
+	gross := client.Payment(t, hourCost)
+	discount, err := cc.discount(t, client, hourCost)
+	if err != nil {
+		return SessionBilled{}, err
+	}
+	payment := gross - discount
 
 	if err = cc.store.UpdateTableBusy(client.Table, false); err != nil {
-		return err
+		return SessionBilled{}, err
 	}
 	if err = cc.store.UpdateTableWorkingTime(client.Table, table.WorkingTime+playingTime); err != nil {
-		return err
+		return SessionBilled{}, err
 	}
 	if err = cc.store.UpdateTableProfit(client.Table, table.Profit+payment); err != nil {
+		return SessionBilled{}, err
+	}
+	if err = cc.store.UpdateTableDiscount(client.Table, table.Discount+discount); err != nil {
+		return SessionBilled{}, err
+	}
+	if err = cc.earnPoints(client.Name, payment, hourCost); err != nil {
+		return SessionBilled{}, err
+	}
+	cc.logger.Info("client left the table", "client", client.Name, "table", client.Table, "played", playingTime, "hour_cost", hourCost, "payment", payment, "discount", discount)
+	return SessionBilled{Time: t, Client: client.Name, Table: client.Table, Played: playingTime, Gross: gross, Discount: discount, Payment: payment}, nil
+}
+
+// HourCost returns the hour cost of a session at table started at since.
+func (cc *ComputerClub) HourCost(table int, since store.DayTime) float64 {
+	return cc.Tariffs.HourCost(cc.MoneyPerHour, table, since)
+}
+
+// discount returns the money the promotion of the client takes off the
+// payment for the session ending at t. Points spent on it are taken off the
+// client.
+func (cc *ComputerClub) discount(t store.DayTime, client store.Client, hourCost float64) (float64, error) {
+	if client.Promotion == nil {
+		return 0, nil
+	}
+	points, err := cc.store.Points(client.Name)
+	if err != nil {
+		return 0, err
+	}
+	discount, spent := client.Promotion.Discount(promo.Session{Hours: client.PlayedHours(t), HourCost: hourCost, Points: points, Loyalty: cc.Loyalty})
+	if spent == 0 {
+		return discount, nil
+	}
+	if err := cc.store.UpdatePoints(client.Name, points-spent); err != nil {
+		return 0, err
+	}
+	cc.logger.Info("points redeemed", "client", client.Name, "points", spent, "discount", discount)
+	return discount, nil
+}
+
+// earnPoints gives the client points for the hours paid for.
+func (cc *ComputerClub) earnPoints(clientName string, payment float64, hourCost float64) error {
+	earned := cc.Loyalty.Earn(payment, hourCost)
+	if earned == 0 {
+		return nil
+	}
+	points, err := cc.store.Points(clientName)
+	if err != nil {
 		return err
 	}
-	return err
+	return cc.store.UpdatePoints(clientName, points+earned)
 }
@@ -1,13 +1,19 @@
 package service_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
 	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
 	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
 	"github.com/GerogeGol/yadro-test-problem/domain/test"
@@ -127,6 +133,14 @@ func TestSitDown(t *testing.T) {
 		err := club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
 		test.AssertError(t, err, service.ClientUnknown)
 	})
+
+	t.Run("table number out of range", func(t *testing.T) {
+		club := dummyClub()
+		_ = club.Arrive(dummyDayTime, dummyClient)
+
+		err := club.SitDown(dummyDayTime, dummyClient, dummyComputersCount+1)
+		test.AssertError(t, err, service.IncorrectTableNumber)
+	})
 }
 
 func TestWait(t *testing.T) {
@@ -169,6 +183,10 @@ func TestWait(t *testing.T) {
 		isWaiting, err = club.Wait(dummyDayTime, newClient2)
 		test.AssertNoError(t, err)
 		test.AssertFalse(t, isWaiting)
+
+		leftClients, err := club.Close()
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(leftClients), 2)
 	})
 	t.Run("client no wating for an empty table", func(t *testing.T) {
 		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
@@ -178,7 +196,134 @@ func TestWait(t *testing.T) {
 	})
 }
 
+func TestWaitTwice(t *testing.T) {
+	t.Run("seated client keeps the table", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		_ = club.Arrive(dummyDayTime, dummyClient)
+		_ = club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
+
+		isWaiting, err := club.Wait(dummyDayTime, dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, isWaiting)
+		test.AssertEqual(t, len(club.Waiting()), 0)
+		test.AssertEqual(t, club.BusyComputers(), 1)
+	})
+
+	t.Run("waiting client is queued once", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		club.QueueCapacity = 2
+		_ = club.Arrive(dummyDayTime, dummyClient)
+		_ = club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
+
+		newClient := "newClient"
+		_ = club.Arrive(dummyDayTime, newClient)
+		_, _ = club.Wait(dummyDayTime, newClient)
+
+		isWaiting, err := club.Wait(dummyDayTime, newClient)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, isWaiting)
+		test.AssertEqual(t, strings.Join(club.Waiting(), " "), newClient)
+	})
+
+	t.Run("seated client keeps the table on a full queue", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		_ = club.Arrive(dummyDayTime, dummyClient)
+		_ = club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
+		_ = club.Arrive(dummyDayTime, "newClient")
+		_, _ = club.Wait(dummyDayTime, "newClient")
+
+		isWaiting, err := club.Wait(dummyDayTime, dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, isWaiting)
+		test.AssertEqual(t, club.BusyComputers(), 1)
+		test.AssertEqual(t, strings.Join(club.Waiting(), " "), "newClient")
+	})
+
+	t.Run("waiting client stays in the full queue", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		_ = club.Arrive(dummyDayTime, dummyClient)
+		_ = club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
+
+		newClient := "newClient"
+		_ = club.Arrive(dummyDayTime, newClient)
+		_, _ = club.Wait(dummyDayTime, newClient)
+
+		isWaiting, err := club.Wait(dummyDayTime, newClient)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, isWaiting)
+		test.AssertEqual(t, strings.Join(club.Waiting(), " "), newClient)
+		clients, _ := club.Clients()
+		test.AssertEqual(t, len(clients), 2)
+	})
+}
+
+func TestReserve(t *testing.T) {
+	club := service.NewComputerClub(2, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+	_ = club.Arrive(dummyDayTime, "a")
+	_ = club.Arrive(dummyDayTime, "b")
+	_ = club.SitDown(dummyDayTime, "a", 1)
+
+	t.Run("busy or unknown table", func(t *testing.T) {
+		test.AssertError(t, club.Reserve(1, "b"), service.PlaceIsBusy)
+		test.AssertError(t, club.Reserve(3, "b"), service.IncorrectTableNumber)
+	})
+
+	t.Run("only the client can sit down at a reserved table", func(t *testing.T) {
+		test.AssertNoError(t, club.Reserve(2, "c"))
+		test.AssertError(t, club.Reserve(2, "b"), service.PlaceIsBusy)
+		test.AssertError(t, club.SitDown(dummyDayTime, "b", 2), service.PlaceIsBusy)
+
+		isWaiting, err := club.Wait(dummyDayTime, "b")
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, isWaiting)
+
+		_ = club.Arrive(dummyDayTime, "c")
+		test.AssertNoError(t, club.SitDown(dummyDayTime, "c", 2))
+		_, ok := club.Reservation(2)
+		test.AssertFalse(t, ok)
+	})
+
+	t.Run("cancelled reservation", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		_ = club.Arrive(dummyDayTime, "a")
+		test.AssertNoError(t, club.Reserve(1, "c"))
+		club.CancelReservation(1)
+		test.AssertNoError(t, club.SitDown(dummyDayTime, "a", 1))
+	})
+}
+
 func TestLeave(t *testing.T) {
+	t.Run("waiting client leaves the queue", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		_ = club.Arrive(dummyDayTime, dummyClient)
+		_ = club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
+
+		newClient := "newClient"
+		_ = club.Arrive(dummyDayTime, newClient)
+		_, _ = club.Wait(dummyDayTime, newClient)
+		_, _, err := club.Leave(dummyDayTime, newClient)
+		test.AssertNoError(t, err)
+
+		_, occupied, err := club.Leave(dummyDayTime, dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertFalse(t, occupied)
+		test.AssertEqual(t, club.BusyComputers(), 0)
+	})
+
+	t.Run("table taken from the queue is counted once", func(t *testing.T) {
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		_ = club.Arrive(dummyDayTime, dummyClient)
+		_ = club.SitDown(dummyDayTime, dummyClient, dummyTableNumber)
+
+		newClient := "newClient"
+		_ = club.Arrive(dummyDayTime, newClient)
+		_, _ = club.Wait(dummyDayTime, newClient)
+		_, occupied, err := club.Leave(dummyDayTime, dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, occupied)
+		test.AssertEqual(t, club.BusyComputers(), 1)
+	})
+
 	t.Run("client arrive and leave", func(t *testing.T) {
 		club := dummyClub()
 		_ = club.Arrive(dummyDayTime, dummyClient)
@@ -331,6 +476,249 @@ func TestProfit(t *testing.T) {
 	})
 }
 
+func TestPromote(t *testing.T) {
+	newClub := func() *service.ComputerClub {
+		return service.NewComputerClub(2, 10, store.NewDayTime(9, 0), dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+	}
+	// play plays from..to at table 1 with the promotion and returns the
+	// billed session
+	play := func(t *testing.T, club *service.ComputerClub, client string, from, to store.DayTime, promotion string) service.SessionBilled {
+		t.Helper()
+		var billed service.SessionBilled
+		cancel := club.DomainEvents().Handle(func(e service.DomainEvent) {
+			if e, ok := e.(service.SessionBilled); ok {
+				billed = e
+			}
+		})
+		defer cancel()
+
+		test.AssertNoError(t, club.Arrive(from, client))
+		if promotion != "" {
+			test.AssertNoError(t, club.Promote(from, client, promotion))
+		}
+		test.AssertNoError(t, club.SitDown(from, client, 1))
+		_, _, err := club.Leave(to, client)
+		test.AssertNoError(t, err)
+		return billed
+	}
+
+	t.Run("discount", func(t *testing.T) {
+		club := newClub()
+		billed := play(t, club, "a", store.NewDayTime(9, 0), store.NewDayTime(11, 30), "-10%")
+		test.AssertEqual(t, billed.Gross, 30)
+		test.AssertEqual(t, billed.Discount, 3)
+		test.AssertEqual(t, billed.Payment, 27)
+
+		tables, err := club.TablesInfo()
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, tables[0].Profit, 27)
+		test.AssertEqual(t, tables[0].Discount, 3)
+		test.AssertEqual(t, tables[0].Gross(), 30)
+		test.AssertEqual(t, tables[0].Discounted(), "1 27 02:30 30 3")
+		test.AssertEqual(t, tables[1].Discounted(), "2 0 00:00 0 0")
+	})
+
+	t.Run("the last promotion attached is applied", func(t *testing.T) {
+		club := newClub()
+		test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+		test.AssertNoError(t, club.Promote(store.NewDayTime(9, 0), "a", "-50"))
+		billed := play(t, club, "b", store.NewDayTime(9, 0), store.NewDayTime(12, 0), "3for2")
+		test.AssertEqual(t, billed.Discount, 10)
+	})
+
+	t.Run("points", func(t *testing.T) {
+		club := newClub()
+		club.Loyalty = promo.Loyalty{PointsPerHour: 1, HourPrice: 2}
+
+		billed := play(t, club, "a", store.NewDayTime(9, 0), store.NewDayTime(12, 0), "")
+		test.AssertEqual(t, billed.Payment, 30)
+		// the points are kept after the client leaves, 3 of them pay for an
+		// hour
+		billed = play(t, club, "a", store.NewDayTime(12, 0), store.NewDayTime(14, 0), "points")
+		test.AssertEqual(t, billed.Discount, 10)
+		test.AssertEqual(t, billed.Payment, 10)
+		// one point is left and one is earned
+		billed = play(t, club, "a", store.NewDayTime(14, 0), store.NewDayTime(15, 0), "points")
+		test.AssertEqual(t, billed.Discount, 10)
+		test.AssertEqual(t, billed.Payment, 0)
+		billed = play(t, club, "a", store.NewDayTime(15, 0), store.NewDayTime(16, 0), "points")
+		test.AssertEqual(t, billed.Discount, 0)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		club := newClub()
+		test.AssertError(t, club.Promote(store.NewDayTime(9, 0), "a", "-10%"), service.ClientUnknown)
+	})
+
+	t.Run("unknown promotion", func(t *testing.T) {
+		club := newClub()
+		test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+		test.AssertError(t, club.Promote(store.NewDayTime(9, 0), "a", "bonus"), promo.UnknownPromotion)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	logged := func(buf *bytes.Buffer) []map[string]any {
+		var records []map[string]any
+		decoder := json.NewDecoder(buf)
+		for decoder.More() {
+			var r map[string]any
+			test.AssertNoError(t, decoder.Decode(&r))
+			records = append(records, r)
+		}
+		return records
+	}
+	find := func(records []map[string]any, msg string) map[string]any {
+		for _, r := range records {
+			if r["msg"] == msg {
+				return r
+			}
+		}
+		t.Fatalf("no %q record in %v", msg, records)
+		return nil
+	}
+
+	t.Run("explains a leave instead of waiting", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, nil))
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue(), service.WithLogger(logger))
+		club.QueueCapacity = 0
+
+		test.AssertNoError(t, club.Arrive(dummyDayTime, dummyClient))
+		isWaiting, err := club.Wait(dummyDayTime, dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertFalse(t, isWaiting)
+
+		r := find(logged(buf), "queue is full, client leaves")
+		test.AssertEqual(t, r["client"], any(dummyClient))
+		test.AssertEqual(t, r["queue_capacity"], any(0.0))
+	})
+
+	t.Run("tells who is promoted from the queue", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, nil))
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue(), service.WithLogger(logger))
+
+		test.AssertNoError(t, club.Arrive(dummyDayTime, "a"))
+		test.AssertNoError(t, club.SitDown(dummyDayTime, "a", 1))
+		test.AssertNoError(t, club.Arrive(dummyDayTime, "b"))
+		_, err := club.Wait(dummyDayTime, "b")
+		test.AssertNoError(t, err)
+		_, _, err = club.Leave(dummyDayTime, "a")
+		test.AssertNoError(t, err)
+
+		r := find(logged(buf), "client promoted from the queue")
+		test.AssertEqual(t, r["client"], any("b"))
+		test.AssertEqual(t, r["table"], any(1.0))
+	})
+
+	t.Run("service logs events with their attributes", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue(), service.WithLogger(logger))
+		s := service.NewService(club)
+
+		s.ServeEvent(event.NewSitDownEvent(dummyDayTime, dummyClient, 1))
+
+		r := find(logged(buf), "event served")
+		test.AssertEqual(t, r["event_id"], any(2.0))
+		test.AssertEqual(t, r["client"], any(dummyClient))
+		test.AssertEqual(t, r["table"], any(1.0))
+		test.AssertEqual(t, r["time"], any("00:00"))
+		test.AssertEqual(t, r["error"], any("ClientUnknown"))
+	})
+
+	t.Run("club records carry the event id and time", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(slog.NewJSONHandler(buf, nil))
+		club := service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue(), service.WithLogger(logger))
+		s := service.NewService(club)
+
+		s.ServeEvent(event.NewArrivalEvent(store.NewDayTime(9, 30), dummyClient))
+		_, err := s.Close()
+		test.AssertNoError(t, err)
+
+		records := logged(buf)
+		r := find(records, "client arrived")
+		test.AssertEqual(t, r["event_id"], any(1.0))
+		test.AssertEqual(t, r["time"], any("09:30"))
+		r = find(records, "club closed")
+		test.AssertEqual(t, r["event_id"], any(11.0))
+		test.AssertEqual(t, r["time"], any(dummyCloseTime.String()))
+	})
+}
+
+func TestDomainEvents(t *testing.T) {
+	club := service.NewComputerClub(2, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	club.QueueCapacity = 1
+	var got []string
+	club.DomainEvents().Handle(func(e service.DomainEvent) {
+		got = append(got, fmt.Sprintf("%T %v", e, e))
+	})
+
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 0), "a", 1))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 10), "a", 2))
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 20), "b"))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 20), "b", 1))
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 30), "c"))
+	_, err := club.Wait(store.NewDayTime(9, 30), "c")
+	test.AssertNoError(t, err)
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 40), "d"))
+	_, err = club.Wait(store.NewDayTime(9, 40), "d")
+	test.AssertNoError(t, err)
+	_, _, err = club.Leave(store.NewDayTime(11, 0), "a")
+	test.AssertNoError(t, err)
+	_, err = club.Close()
+	test.AssertNoError(t, err)
+
+	want := `service.ClientArrived {09:00 a}
+service.ClientSeated {09:00 a 1 false}
+service.TableChanged {09:10 a 1 2}
+service.ClientArrived {09:20 b}
+service.ClientSeated {09:20 b 1 false}
+service.ClientArrived {09:30 c}
+service.ClientQueued {09:30 c 1}
+service.ClientArrived {09:40 d}
+service.ClientLeft {09:40 d 0}
+service.SessionBilled {11:00 a 2 2h0m0s 20 0 20}
+service.ClientLeft {11:00 a 2}
+service.ClientSeated {11:00 c 2 true}
+service.SessionBilled {19:00 b 1 9h40m0s 100 0 100}
+service.ClientLeft {19:00 b 1}
+service.SessionBilled {19:00 c 2 8h0m0s 80 0 80}
+service.ClientLeft {19:00 c 2}
+service.ClubClosed {19:00 [b c]}`
+	if diff := test.LineDiff(want, strings.Join(got, "\n")); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestDomainEventsAfterStateChange(t *testing.T) {
+	club := service.NewComputerClub(1, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	var busy []int
+	var waiting []string
+	club.DomainEvents().Handle(func(e service.DomainEvent) {
+		switch e.(type) {
+		case service.ClientLeft, service.ClientSeated:
+			busy = append(busy, club.BusyComputers())
+			waiting = append(waiting, strings.Join(club.Waiting(), ","))
+		}
+	})
+
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 0), "a", 1))
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 10), "b"))
+	_, err := club.Wait(store.NewDayTime(9, 10), "b")
+	test.AssertNoError(t, err)
+	busy, waiting = nil, nil
+	_, _, err = club.Leave(store.NewDayTime(10, 0), "a")
+	test.AssertNoError(t, err)
+
+	test.AssertEqual(t, fmt.Sprint(busy), "[1 1]")
+	test.AssertEqual(t, strings.Join(waiting, ";"), ";")
+}
+
 func dummyClub() *service.ComputerClub {
 	return service.NewComputerClub(dummyComputersCount, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
 }
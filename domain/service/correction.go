@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+// WithReplay lets the service serve corrections. newState returns an empty
+// store and queue like the ones of the club, the corrected events are
+// served again into them and they replace the state of the club. The
+// service keeps every event served and every session billed until the end
+// of the day for that, without WithReplay it keeps nothing and rejects the
+// corrections.
+func WithReplay(newState func() (store.Store, queue.Queue)) ServiceOption {
+	return func(s *Service) {
+		s.newState = newState
+		s.cc.domainEvents.Handle(func(e DomainEvent) {
+			if billed, ok := e.(SessionBilled); ok {
+				s.billed = append(s.billed, billed)
+			}
+		})
+	}
+}
+
+// correct voids or amends an earlier event and recomputes the state of the
+// club from the corrected events. The club is left as it was if the
+// correction is rejected.
+func (s *Service) correct(e event.InputEvent) error {
+	if s.newState == nil {
+		return CorrectionRejected
+	}
+	var seq int
+	if c, ok := e.(interface{ Seq() int }); ok {
+		seq = c.Seq()
+	}
+	target, err := s.served(seq)
+	if err != nil {
+		return err
+	}
+
+	corrected := maps.Clone(s.corrected)
+	if corrected == nil {
+		corrected = map[int]event.InputEvent{}
+	}
+	switch e := e.(type) {
+	case *event.VoidEvent:
+		corrected[seq] = nil
+	case *event.AmendEvent:
+		if corrected[seq], err = amend(target, e); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Service.ServeEvent: cant interpret event to a correction")
+	}
+
+	club, billed, err := s.replay(corrected)
+	if err != nil {
+		return err
+	}
+	unbilled, rebilled := billedDiff(s.billed, billed)
+	s.corrected, s.billed = corrected, billed
+	s.cc.store, s.cc.queue, s.cc.busyComputers = club.store, club.queue, club.busyComputers
+	s.cc.logger.Info("event corrected", "seq", seq, "event", target, "correction", e)
+	s.cc.domainEvents.Publish(EventCorrected{Time: e.Time(), Seq: seq, Voided: e.Id() == event.VoidEventId, Unbilled: unbilled, Billed: rebilled})
+	return nil
+}
+
+// billedDiff returns the sessions of before missing from after and the ones
+// of after missing from before.
+func billedDiff(before, after []SessionBilled) (gone, added []SessionBilled) {
+	added = slices.Clone(after)
+	for _, b := range before {
+		i := slices.IndexFunc(added, func(a SessionBilled) bool {
+			return a.Time.Equal(b.Time) && a.Client == b.Client && a.Table == b.Table && a.Played == b.Played &&
+				a.Gross == b.Gross && a.Discount == b.Discount && a.Payment == b.Payment
+		})
+		if i < 0 {
+			gone = append(gone, b)
+			continue
+		}
+		added = slices.Delete(added, i, i+1)
+	}
+	return gone, added
+}
+
+// served returns the event with the sequence number seq as it stands after
+// the corrections.
+func (s *Service) served(seq int) (event.InputEvent, error) {
+	if seq < 1 || seq > len(s.history) || event.IsCorrection(s.history[seq-1]) {
+		return nil, EventUnknown
+	}
+	e, ok := s.corrected[seq]
+	if !ok {
+		return s.history[seq-1], nil
+	}
+	if e == nil {
+		return nil, EventUnknown
+	}
+	return e, nil
+}
+
+func amend(target event.InputEvent, a *event.AmendEvent) (event.InputEvent, error) {
+	switch target := target.(type) {
+	case *event.SitDownEvent:
+		table := target.Table()
+		if a.Table() != 0 {
+			table = a.Table()
+		}
+		return event.NewSitDownEvent(target.Time(), a.Client(), table), nil
+	case *event.PromotionEvent:
+		if a.Table() != 0 {
+			return nil, CorrectionRejected
+		}
+		return event.NewPromotionEvent(target.Time(), a.Client(), target.Promotion()), nil
+	}
+	if a.Table() != 0 {
+		return nil, CorrectionRejected
+	}
+	switch target.Id() {
+	case event.ArrivalEventId:
+		return event.NewArrivalEvent(target.Time(), a.Client()), nil
+	case event.WaitEventId:
+		return event.NewWaitEvent(target.Time(), a.Client()), nil
+	case event.LeaveEventId:
+		return event.NewLeaveEvent(target.Time(), a.Client()), nil
+	}
+	return nil, EventUnknown
+}
+
+// Corrected returns the input events served so far as they stand after the
+// corrections: voided events and the corrections themselves are left out and
+// amended events are replaced. It is empty without WithReplay.
+func (s *Service) Corrected() []event.InputEvent {
+	return s.apply(s.corrected)
+}
+
+func (s *Service) apply(corrected map[int]event.InputEvent) []event.InputEvent {
+	var events []event.InputEvent
+	for i, e := range s.history {
+		if event.IsCorrection(e) {
+			continue
+		}
+		if c, ok := corrected[i+1]; ok {
+			if c == nil {
+				continue
+			}
+			e = c
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// replay serves the history with the corrections into a club of its own,
+// one that tells nobody about the events, and returns it with the sessions
+// it billed.
+func (s *Service) replay(corrected map[int]event.InputEvent) (*ComputerClub, []SessionBilled, error) {
+	st, q := s.newState()
+	club := NewComputerClub(s.cc.ComputerCount, s.cc.MoneyPerHour, s.cc.OpenTime, s.cc.CloseTime, st, q)
+	club.QueueCapacity = s.cc.QueueCapacity
+	club.Loyalty = s.cc.Loyalty
+	club.Tariffs = s.cc.Tariffs
+	replay := &Service{cc: club, events: bus.New[event.Event]()}
+	var billed []SessionBilled
+	club.domainEvents.Handle(func(e DomainEvent) {
+		if b, ok := e.(SessionBilled); ok {
+			billed = append(billed, b)
+		}
+	})
+
+	for _, e := range s.apply(corrected) {
+		out := replay.serveEvent(e)
+		if errEvent, ok := out.(*event.ErrorEvent); ok && !IsRecoverable(errEvent.Err()) {
+			var de *DomainError
+			if errors.As(errEvent.Err(), &de) {
+				return nil, nil, CorrectionRejected
+			}
+			return nil, nil, fmt.Errorf("Service.ServeEvent: %w", errEvent.Err())
+		}
+	}
+	return club, billed, nil
+}
@@ -0,0 +1,166 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestCorrections(t *testing.T) {
+	newService := func() *service.Service {
+		club := service.NewComputerClub(dummyComputersCount, 10, store.NewDayTime(9, 0), dummyCloseTime, memstore.NewStore(), memqueue.NewQueue())
+		return service.NewService(club, service.WithReplay(func() (store.Store, queue.Queue) {
+			return memstore.NewStore(), memqueue.NewQueue()
+		}))
+	}
+	serve := func(t *testing.T, s *service.Service, events ...event.InputEvent) {
+		t.Helper()
+		for _, e := range events {
+			assertNoErrorEvent(t, s.ServeEvent(e))
+		}
+	}
+	profits := func(t *testing.T, s *service.Service) []float64 {
+		t.Helper()
+		tables, err := s.Profit()
+		test.AssertNoError(t, err)
+		var got []float64
+		for _, table := range tables {
+			got = append(got, table.Profit)
+		}
+		return got
+	}
+
+	t.Run("void leave", func(t *testing.T) {
+		s := newService()
+		serve(t, s,
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+			event.NewSitDownEvent(store.NewDayTime(9, 0), "a", 1),
+			event.NewLeaveEvent(store.NewDayTime(11, 0), "a"),
+		)
+		test.AssertEqual(t, profits(t, s)[0], 20)
+
+		serve(t, s, event.NewVoidEvent(store.NewDayTime(11, 30), 3))
+		test.AssertEqual(t, profits(t, s)[0], 0)
+
+		clients, err := s.Close()
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(clients), 1)
+		test.AssertEqual(t, profits(t, s)[0], 100)
+	})
+
+	t.Run("amend table", func(t *testing.T) {
+		s := newService()
+		serve(t, s,
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+			event.NewSitDownEvent(store.NewDayTime(9, 0), "a", 1),
+			event.NewLeaveEvent(store.NewDayTime(10, 0), "a"),
+			event.NewAmendEvent(store.NewDayTime(10, 5), 2, "a", 2),
+		)
+		got := profits(t, s)
+		test.AssertEqual(t, got[0], 0)
+		test.AssertEqual(t, got[1], 10)
+	})
+
+	t.Run("amend client", func(t *testing.T) {
+		s := newService()
+		serve(t, s, event.NewArrivalEvent(store.NewDayTime(9, 0), "a"))
+		assertErrorEvent(t, s.ServeEvent(event.NewSitDownEvent(store.NewDayTime(9, 0), "b", 1)), service.ClientUnknown)
+
+		serve(t, s,
+			event.NewAmendEvent(store.NewDayTime(9, 5), 2, "a", 0),
+			event.NewLeaveEvent(store.NewDayTime(10, 0), "a"),
+		)
+		test.AssertEqual(t, profits(t, s)[0], 10)
+	})
+
+	t.Run("amend promotion", func(t *testing.T) {
+		s := newService()
+		serve(t, s,
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "b"),
+			event.NewPromotionEvent(store.NewDayTime(9, 0), "a", "-50%"),
+			event.NewAmendEvent(store.NewDayTime(9, 5), 3, "b", 0),
+			event.NewSitDownEvent(store.NewDayTime(9, 10), "a", 1),
+			event.NewSitDownEvent(store.NewDayTime(9, 10), "b", 2),
+			event.NewLeaveEvent(store.NewDayTime(10, 0), "a"),
+			event.NewLeaveEvent(store.NewDayTime(10, 0), "b"),
+		)
+		got := profits(t, s)
+		test.AssertEqual(t, got[0], 10)
+		test.AssertEqual(t, got[1], 5)
+		assertErrorEvent(t, s.ServeEvent(event.NewAmendEvent(store.NewDayTime(10, 5), 3, "b", 1)), service.CorrectionRejected)
+	})
+
+	t.Run("unknown events", func(t *testing.T) {
+		s := newService()
+		serve(t, s,
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+			event.NewVoidEvent(store.NewDayTime(9, 5), 1),
+		)
+		// voided already
+		assertErrorEvent(t, s.ServeEvent(event.NewVoidEvent(store.NewDayTime(9, 10), 1)), service.EventUnknown)
+		// a correction
+		assertErrorEvent(t, s.ServeEvent(event.NewVoidEvent(store.NewDayTime(9, 10), 2)), service.EventUnknown)
+		// not served yet
+		assertErrorEvent(t, s.ServeEvent(event.NewVoidEvent(store.NewDayTime(9, 10), 10)), service.EventUnknown)
+		assertErrorEvent(t, s.ServeEvent(event.NewVoidEvent(store.NewDayTime(9, 10), 0)), service.EventUnknown)
+	})
+
+	t.Run("billing taken back", func(t *testing.T) {
+		s := newService()
+		var corrected []service.EventCorrected
+		s.Club().DomainEvents().Handle(func(e service.DomainEvent) {
+			if c, ok := e.(service.EventCorrected); ok {
+				corrected = append(corrected, c)
+			}
+		})
+		serve(t, s,
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+			event.NewSitDownEvent(store.NewDayTime(9, 0), "a", 1),
+			event.NewLeaveEvent(store.NewDayTime(11, 0), "a"),
+			event.NewAmendEvent(store.NewDayTime(11, 5), 2, "a", 2),
+			event.NewVoidEvent(store.NewDayTime(11, 10), 3),
+		)
+
+		test.AssertEqual(t, len(corrected), 2)
+		amended := corrected[0]
+		test.AssertEqual(t, len(amended.Unbilled), 1)
+		test.AssertEqual(t, amended.Unbilled[0].Table, 1)
+		test.AssertEqual(t, len(amended.Billed), 1)
+		test.AssertEqual(t, amended.Billed[0].Table, 2)
+		test.AssertEqual(t, amended.Billed[0].Payment, 20)
+
+		voided := corrected[1]
+		test.AssertTrue(t, voided.Voided)
+		test.AssertEqual(t, len(voided.Unbilled), 1)
+		test.AssertEqual(t, voided.Unbilled[0].Table, 2)
+		test.AssertEqual(t, len(voided.Billed), 0)
+	})
+
+	t.Run("rejected corrections", func(t *testing.T) {
+		s := newService()
+		serve(t, s, event.NewArrivalEvent(store.NewDayTime(9, 0), "a"))
+		assertErrorEvent(t, s.ServeEvent(event.NewAmendEvent(store.NewDayTime(9, 5), 1, "a", 2)), service.CorrectionRejected)
+
+		s = service.NewService(dummyClub())
+		serve(t, s, event.NewArrivalEvent(dummyDayTime, dummyClient))
+		assertErrorEvent(t, s.ServeEvent(event.NewVoidEvent(dummyDayTime, 1)), service.CorrectionRejected)
+	})
+
+	t.Run("rejected correction keeps the state", func(t *testing.T) {
+		s := newService()
+		serve(t, s,
+			event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+			event.NewSitDownEvent(store.NewDayTime(9, 0), "a", 1),
+			event.NewLeaveEvent(store.NewDayTime(10, 0), "a"),
+		)
+		assertErrorEvent(t, s.ServeEvent(event.NewAmendEvent(store.NewDayTime(10, 5), 3, "a", 2)), service.CorrectionRejected)
+		test.AssertEqual(t, profits(t, s)[0], 10)
+	})
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+// DomainEvent is a change of the club state. The club publishes them on its
+// bus for whatever follows the club, audit, notifications or reports, so
+// that the club itself knows nothing of them.
+type DomainEvent interface {
+	At() store.DayTime
+}
+
+type ClientArrived struct {
+	Time   store.DayTime
+	Client string
+}
+
+// ClientSeated is published when a client takes a free table, by sitting
+// down or by getting the table from the queue.
+type ClientSeated struct {
+	Time      store.DayTime
+	Client    string
+	Table     int
+	FromQueue bool
+}
+
+// TableChanged is published when a seated client moves to another table.
+// The session goes on, it is billed when the client leaves.
+type TableChanged struct {
+	Time     store.DayTime
+	Client   string
+	Previous int
+	Table    int
+}
+
+type ClientQueued struct {
+	Time   store.DayTime
+	Client string
+	// Position is the place in the queue, 1 for the next to get a table.
+	Position int
+}
+
+// ClientLeft is published when a client leaves the club, by themselves,
+// because the queue is full or at the close time. Table is zero for the
+// clients who had none.
+type ClientLeft struct {
+	Time   store.DayTime
+	Client string
+	Table  int
+}
+
+// SessionBilled is published when a client leaves a table, before
+// ClientLeft. Payment is what the client pays, Gross less Discount.
+type SessionBilled struct {
+	Time     store.DayTime
+	Client   string
+	Table    int
+	Played   time.Duration
+	Gross    float64
+	Discount float64
+	Payment  float64
+}
+
+// PromotionAttached is published when a promotion is attached to a client.
+type PromotionAttached struct {
+	Time      store.DayTime
+	Client    string
+	Promotion string
+}
+
+// ClubClosed is published after the clients left at the close time are sent
+// away.
+type ClubClosed struct {
+	Time     store.DayTime
+	SentAway []string
+}
+
+// EventCorrected is published when an earlier input event is voided or
+// amended. The state of the club is recomputed from the corrected events.
+// Unbilled are the sessions billed before that the corrected events don't
+// bill and Billed the ones they bill instead, whoever keeps totals takes
+// back the first and adds the second; the other events published meanwhile
+// are not taken back.
+type EventCorrected struct {
+	Time     store.DayTime
+	Seq      int
+	Voided   bool
+	Unbilled []SessionBilled
+	Billed   []SessionBilled
+}
+
+// EventServed is published by the service after every input event, once
+// the club is done with it. Err is the error the club answered with, nil
+// if there is none; BusyTables and QueueLength are the occupancy after the
+// event.
+type EventServed struct {
+	Time        store.DayTime
+	ID          int
+	Err         error
+	BusyTables  int
+	QueueLength int
+}
+
+func (e ClientArrived) At() store.DayTime     { return e.Time }
+func (e ClientSeated) At() store.DayTime      { return e.Time }
+func (e TableChanged) At() store.DayTime      { return e.Time }
+func (e ClientQueued) At() store.DayTime      { return e.Time }
+func (e ClientLeft) At() store.DayTime        { return e.Time }
+func (e SessionBilled) At() store.DayTime     { return e.Time }
+func (e ClubClosed) At() store.DayTime        { return e.Time }
+func (e PromotionAttached) At() store.DayTime { return e.Time }
+func (e EventCorrected) At() store.DayTime    { return e.Time }
+func (e EventServed) At() store.DayTime       { return e.Time }
+
+// WithDomainEvents makes the club publish its domain events on b instead of
+// a bus of its own.
+func WithDomainEvents(b *bus.Bus[DomainEvent]) Option {
+	return func(cc *ComputerClub) {
+		cc.domainEvents = b
+	}
+}
+
+// DomainEvents returns the bus the club publishes its domain events on.
+// Attach a handler to it to get every event as it happens, or subscribe to
+// follow the club without holding it up.
+func (cc *ComputerClub) DomainEvents() *bus.Bus[DomainEvent] {
+	return cc.domainEvents
+}
@@ -0,0 +1,80 @@
+package service
+
+import "errors"
+
+type Severity int
+
+const (
+	// Recoverable errors are reported with an ErrorEvent and the club goes on.
+	Recoverable Severity = iota
+	// Fatal errors mean the input is incorrect and processing has to stop.
+	Fatal
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Recoverable:
+		return "recoverable"
+	case Fatal:
+		return "fatal"
+	}
+	return "unknown"
+}
+
+// DomainError is a violation of a club rule. Code is stable and meant for
+// machines, Name is what the output protocol prints.
+type DomainError struct {
+	Code     string
+	Name     string
+	Severity Severity
+}
+
+func (e *DomainError) Error() string {
+	return e.Name
+}
+
+var YouShallNotPass = &DomainError{Code: "CLIENT_ALREADY_IN_CLUB", Name: "YouShallNotPass", Severity: Recoverable}
+var NotOpenYet = &DomainError{Code: "CLUB_CLOSED", Name: "NotOpenYet", Severity: Recoverable}
+var PlaceIsBusy = &DomainError{Code: "TABLE_BUSY", Name: "PlaceIsBusy", Severity: Recoverable}
+var ClientUnknown = &DomainError{Code: "CLIENT_UNKNOWN", Name: "ClientUnknown", Severity: Recoverable}
+var ICanWaitNoLonger = &DomainError{Code: "FREE_TABLE_AVAILABLE", Name: "ICanWaitNoLonger!", Severity: Recoverable}
+var IncorrectTableNumber = &DomainError{Code: "TABLE_OUT_OF_RANGE", Name: "IncorrectTableNumber", Severity: Fatal}
+
+// EventUnknown is returned for a correction of an event that wasn't served,
+// was voided or is a correction itself.
+var EventUnknown = &DomainError{Code: "EVENT_UNKNOWN", Name: "EventUnknown", Severity: Recoverable}
+
+// CorrectionRejected is returned for a correction the club can't make: the
+// corrected events would be incorrect input, a table is given for an event
+// without one, or the service doesn't keep the history.
+var CorrectionRejected = &DomainError{Code: "CORRECTION_REJECTED", Name: "CorrectionRejected", Severity: Recoverable}
+
+// Catalogue lists every error the club can return for an event.
+var Catalogue = []*DomainError{
+	YouShallNotPass,
+	NotOpenYet,
+	PlaceIsBusy,
+	ClientUnknown,
+	ICanWaitNoLonger,
+	IncorrectTableNumber,
+	EventUnknown,
+	CorrectionRejected,
+}
+
+// ErrorCode returns the code of a domain error, "INTERNAL" for any other
+// error, so that machine readable output doesn't change with the protocol
+// names.
+func ErrorCode(err error) string {
+	var de *DomainError
+	if errors.As(err, &de) {
+		return de.Code
+	}
+	return "INTERNAL"
+}
+
+// IsRecoverable reports whether err is a domain error after which the club
+// can go on serving events.
+func IsRecoverable(err error) bool {
+	var de *DomainError
+	return errors.As(err, &de) && de.Severity == Recoverable
+}
@@ -0,0 +1,37 @@
+package service_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestDomainErrors(t *testing.T) {
+	t.Run("codes and names are unique", func(t *testing.T) {
+		codes := map[string]bool{}
+		names := map[string]bool{}
+		for _, e := range service.Catalogue {
+			test.AssertFalse(t, codes[e.Code])
+			test.AssertFalse(t, names[e.Name])
+			codes[e.Code], names[e.Name] = true, true
+		}
+	})
+
+	t.Run("severity", func(t *testing.T) {
+		test.AssertTrue(t, service.IsRecoverable(service.PlaceIsBusy))
+		test.AssertTrue(t, service.IsRecoverable(fmt.Errorf("wrapped: %w", service.ClientUnknown)))
+		test.AssertFalse(t, service.IsRecoverable(service.IncorrectTableNumber))
+		test.AssertFalse(t, service.IsRecoverable(fmt.Errorf("not a domain error")))
+	})
+
+	t.Run("code", func(t *testing.T) {
+		test.AssertEqual(t, service.ErrorCode(fmt.Errorf("wrapped: %w", service.PlaceIsBusy)), "TABLE_BUSY")
+		test.AssertEqual(t, service.ErrorCode(fmt.Errorf("not a domain error")), "INTERNAL")
+	})
+
+	t.Run("protocol output is the name", func(t *testing.T) {
+		test.AssertEqual(t, service.ICanWaitNoLonger.Error(), "ICanWaitNoLonger!")
+	})
+}
@@ -0,0 +1,64 @@
+package event
+
+import (
+	"fmt"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+var VoidEventId = 5
+var AmendEventId = 6
+
+// IsCorrection reports whether e corrects an earlier event rather than
+// tells what happened in the club.
+func IsCorrection(e Event) bool {
+	return e.Id() == VoidEventId || e.Id() == AmendEventId
+}
+
+// VoidEvent cancels the input event with the sequence number Seq, as if it
+// never happened. Input events are numbered from 1 in the order they come,
+// corrections included.
+type VoidEvent struct {
+	InputEvent
+	seq int
+}
+
+func NewVoidEvent(t store.DayTime, seq int) *VoidEvent {
+	return &VoidEvent{InputEvent: newInputEvent(t, VoidEventId, ""), seq: seq}
+}
+
+func (e *VoidEvent) Seq() int {
+	return e.seq
+}
+
+func (e *VoidEvent) String() string {
+	return fmt.Sprintf("%s %d %d", e.Time(), e.Id(), e.Seq())
+}
+
+// AmendEvent replaces the client of the input event with the sequence
+// number Seq, and the table if it is a SitDownEvent and Table isn't zero.
+// The promotion of a PromotionEvent is kept, void it to take it back.
+type AmendEvent struct {
+	InputEvent
+	seq   int
+	table int
+}
+
+func NewAmendEvent(t store.DayTime, seq int, client string, table int) *AmendEvent {
+	return &AmendEvent{InputEvent: newInputEvent(t, AmendEventId, client), seq: seq, table: table}
+}
+
+func (e *AmendEvent) Seq() int {
+	return e.seq
+}
+
+func (e *AmendEvent) Table() int {
+	return e.table
+}
+
+func (e *AmendEvent) String() string {
+	if e.table == 0 {
+		return fmt.Sprintf("%s %d %d %s", e.Time(), e.Id(), e.Seq(), e.Client())
+	}
+	return fmt.Sprintf("%s %d %d %s %d", e.Time(), e.Id(), e.Seq(), e.Client(), e.Table())
+}
@@ -0,0 +1,28 @@
+package event
+
+import (
+	"fmt"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+var PromotionEventId = 7
+
+// PromotionEvent attaches a promotion to a client in the club, it is applied
+// when the client pays.
+type PromotionEvent struct {
+	InputEvent
+	promotion string
+}
+
+func NewPromotionEvent(t store.DayTime, client string, promotion string) *PromotionEvent {
+	return &PromotionEvent{InputEvent: newInputEvent(t, PromotionEventId, client), promotion: promotion}
+}
+
+func (e *PromotionEvent) Promotion() string {
+	return e.promotion
+}
+
+func (e *PromotionEvent) String() string {
+	return fmt.Sprintf("%s %d %s %s", e.Time(), e.Id(), e.Client(), e.Promotion())
+}
@@ -0,0 +1,35 @@
+package event
+
+// Record is the JSON form of an event, with the keys of the JSON Lines
+// input:
+//
+//	{"time": "09:54", "id": 12, "client": "client4", "table": 1}
+type Record struct {
+	Time      string `json:"time"`
+	ID        int    `json:"id"`
+	Seq       int    `json:"seq,omitempty"`
+	Client    string `json:"client,omitempty"`
+	Table     int    `json:"table,omitempty"`
+	Promotion string `json:"promotion,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+func NewRecord(e Event) Record {
+	r := Record{Time: e.Time().String(), ID: e.Id()}
+	if c, ok := e.(interface{ Seq() int }); ok {
+		r.Seq = c.Seq()
+	}
+	if c, ok := e.(interface{ Client() string }); ok {
+		r.Client = c.Client()
+	}
+	if t, ok := e.(interface{ Table() int }); ok {
+		r.Table = t.Table()
+	}
+	if p, ok := e.(*PromotionEvent); ok {
+		r.Promotion = p.Promotion()
+	}
+	if errEvent, ok := e.(*ErrorEvent); ok {
+		r.Error = errEvent.Err().Error()
+	}
+	return r
+}
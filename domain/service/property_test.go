@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+var propertyRuns = flag.Int("property.runs", 500, "random event sequences checked by TestClubInvariants")
+var propertySeed = flag.Int64("property.seed", 0, "first seed for TestClubInvariants")
+
+const (
+	propertyTables  = 3
+	propertyClients = 6
+	propertyQueue   = 1
+	propertyEvents  = 60
+	propertyCost    = 10.0
+)
+
+var propertyOpen = store.NewDayTime(9, 0)
+var propertyClose = store.NewDayTime(19, 0)
+
+func TestClubInvariants(t *testing.T) {
+	for i := 0; i < *propertyRuns; i++ {
+		seed := *propertySeed + int64(i)
+		events := randomEvents(rand.New(rand.NewSource(seed)), propertyEvents)
+
+		if err := checkInvariants(events); err != nil {
+			shrunk := test.Shrink(events, func(events []event.InputEvent) bool {
+				return checkInvariants(events) != nil
+			})
+			t.Fatalf("seed %d: %v\nminimal sequence:\n%s", seed, checkInvariants(shrunk), formatEvents(shrunk))
+		}
+	}
+}
+
+// randomEvents returns well-formed events in time order: a few clients
+// arrive, sit at tables in range, wait and leave in random order, so that
+// the club also answers with errors.
+func randomEvents(rnd *rand.Rand, n int) []event.InputEvent {
+	var events []event.InputEvent
+	t := propertyOpen.Add(-30 * time.Minute)
+	for i := 0; i < n; i++ {
+		t = t.Add(time.Duration(rnd.Intn(30)) * time.Minute)
+		at := store.DayTime{Time: t}
+		client := fmt.Sprintf("client%d", rnd.Intn(propertyClients))
+
+		switch rnd.Intn(4) {
+		case 0:
+			events = append(events, event.NewArrivalEvent(at, client))
+		case 1:
+			events = append(events, event.NewSitDownEvent(at, client, 1+rnd.Intn(propertyTables)))
+		case 2:
+			events = append(events, event.NewWaitEvent(at, client))
+		case 3:
+			events = append(events, event.NewLeaveEvent(at, client))
+		}
+	}
+	return events
+}
+
+// checkInvariants serves events one by one and returns the first invariant
+// that doesn't hold, checking them after every event and after Close.
+func checkInvariants(events []event.InputEvent) error {
+	st := memstore.NewStore()
+	q := memqueue.NewQueue()
+	cc := service.NewComputerClub(propertyTables, propertyCost, propertyOpen, propertyClose, st, q)
+	cc.QueueCapacity = propertyQueue
+	s := service.NewService(cc)
+
+	var payments float64
+	pay := func(t store.DayTime, name string) {
+		if client, err := st.Client(name); err == nil && client.Table != 0 {
+			payments += client.Payment(t, propertyCost)
+		}
+	}
+
+	for i, e := range events {
+		if e.Id() == event.LeaveEventId {
+			pay(e.Time(), e.Client())
+		}
+		out := s.ServeEvent(e)
+		if err := checkState(cc, st, q, payments); err != nil {
+			return fmt.Errorf("after event %d %q: %w", i+1, e, err)
+		}
+		if left, ok := out.(*event.OutLeaveEvent); ok {
+			if exists, _ := st.IsClientExists(left.Client()); exists {
+				return fmt.Errorf("after event %d %q: %s is sent away and still in the club", i+1, e, left.Client())
+			}
+		}
+	}
+
+	clients, _ := st.Clients()
+	for _, client := range clients {
+		pay(propertyClose, client.Name)
+	}
+	if _, err := s.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if err := checkState(cc, st, q, payments); err != nil {
+		return fmt.Errorf("after close: %w", err)
+	}
+
+	if clients, _ := st.Clients(); len(clients) != 0 {
+		return fmt.Errorf("after close: %d clients are left in the store", len(clients))
+	}
+	if q.Len() != 0 {
+		return fmt.Errorf("after close: %d clients are left in the queue", q.Len())
+	}
+	return nil
+}
+
+func checkState(cc *service.ComputerClub, st *memstore.MemoryStore, q *memqueue.Queue, payments float64) error {
+	busy := 0
+	var profit float64
+	for i := 1; i <= cc.ComputerCount; i++ {
+		table, _ := st.Table(i)
+		if table.IsBusy {
+			busy++
+		}
+		profit += table.Profit
+	}
+	if busy != cc.BusyComputers() {
+		return fmt.Errorf("%d tables are busy in the store, the club counts %d", busy, cc.BusyComputers())
+	}
+
+	seatedAt := map[int]string{}
+	clients, _ := st.Clients()
+	for _, client := range clients {
+		if client.Table == 0 {
+			continue
+		}
+		if other, ok := seatedAt[client.Table]; ok {
+			return fmt.Errorf("%s and %s share table %d", other, client.Name, client.Table)
+		}
+		seatedAt[client.Table] = client.Name
+	}
+
+	if q.Len() > cc.QueueCapacity {
+		return fmt.Errorf("%d clients wait, the queue capacity is %d", q.Len(), cc.QueueCapacity)
+	}
+
+	if profit != payments {
+		return fmt.Errorf("the tables made %v, the clients paid %v", profit, payments)
+	}
+	return nil
+}
+
+func formatEvents(events []event.InputEvent) string {
+	var b strings.Builder
+	for _, e := range events {
+		fmt.Fprintln(&b, e)
+	}
+	return b.String()
+}
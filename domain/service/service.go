@@ -1,21 +1,118 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"sort"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
 	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
 )
 
 type Service struct {
-	cc *ComputerClub
+	cc     *ComputerClub
+	events *bus.Bus[event.Event]
+	// history holds the input events served, the one with sequence number
+	// n at n-1; corrected maps a sequence number to the amended event or
+	// to nil for a voided one; billed holds the sessions billed so far. They
+	// are kept only WithReplay.
+	history   []event.InputEvent
+	corrected map[int]event.InputEvent
+	billed    []SessionBilled
+	newState  func() (store.Store, queue.Queue)
 }
 
-func NewService(cc *ComputerClub) *Service {
-	return &Service{cc: cc}
+// ServiceOption configures a Service.
+type ServiceOption func(*Service)
+
+// WithEvents makes the service publish the events it generates on b
+// instead of a bus of its own.
+func WithEvents(b *bus.Bus[event.Event]) ServiceOption {
+	return func(s *Service) {
+		s.events = b
+	}
+}
+
+func NewService(cc *ComputerClub, opts ...ServiceOption) *Service {
+	s := &Service{cc: cc, events: bus.New[event.Event]()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Club returns the club the service serves events for.
+func (s *Service) Club() *ComputerClub {
+	return s.cc
+}
+
+// Subscribe returns a subscription to the events the service generates:
+// OutLeaveEvent, OutSitDownEvent and ErrorEvent. Unsubscribe it when done.
+func (s *Service) Subscribe(buffer int) *bus.Subscription[event.Event] {
+	return s.events.Subscribe(buffer)
 }
 
 func (s *Service) ServeEvent(e event.InputEvent) event.Event {
+	restoreLogger := s.logAs(e.Id(), e.Time())
+	out := s.serveEvent(e)
+	restoreLogger()
+	if s.newState != nil {
+		s.history = append(s.history, e)
+	}
+	var err error
+	if errEvent, ok := out.(*event.ErrorEvent); ok {
+		err = errEvent.Err()
+	}
+	s.logEvent(e, err)
+	if !event.IsEmpty(out) {
+		s.events.Publish(out)
+	}
+	// a plain run has nobody listening, it shouldn't pay for the events
+	if s.cc.domainEvents.Listened() {
+		s.cc.domainEvents.Publish(EventServed{Time: e.Time(), ID: e.Id(), Err: err, BusyTables: s.cc.busyComputers, QueueLength: s.cc.queue.Len()})
+	}
+	return out
+}
+
+// logAs makes the club log its records with the id and time of the event
+// being served, until the returned func is called.
+func (s *Service) logAs(id int, t store.DayTime) func() {
+	logger := s.cc.logger
+	if !logger.Enabled(context.Background(), slog.LevelInfo) {
+		return func() {}
+	}
+	s.cc.logger = logger.With("event_id", id, "time", t)
+	return func() { s.cc.logger = logger }
+}
+
+// logEvent logs every event at the debug level and the ones that stop the
+// club at the error level.
+func (s *Service) logEvent(e event.InputEvent, err error) {
+	level := slog.LevelDebug
+	if err != nil && !IsRecoverable(err) {
+		level = slog.LevelError
+	}
+	if !s.cc.logger.Enabled(context.Background(), level) {
+		return
+	}
+
+	attrs := []any{"event_id", e.Id(), "time", e.Time(), "client", e.Client()}
+	if sitDown, ok := e.(*event.SitDownEvent); ok {
+		attrs = append(attrs, "table", sitDown.Table())
+	}
+	if promotion, ok := e.(*event.PromotionEvent); ok {
+		attrs = append(attrs, "promotion", promotion.Promotion())
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	s.cc.logger.Log(context.Background(), level, "event served", attrs...)
+}
+
+func (s *Service) serveEvent(e event.InputEvent) event.Event {
 	switch e.Id() {
 	case event.ArrivalEventId:
 		arrivalEvent, ok := e.(*event.ArriveEvent)
@@ -65,12 +162,27 @@ func (s *Service) ServeEvent(e event.InputEvent) event.Event {
 		if occupied {
 			return event.NewOutSitDownEvent(e.Time(), client.Name, client.Table)
 		}
+	case event.PromotionEventId:
+		promotionEvent, ok := e.(*event.PromotionEvent)
+		if !ok {
+			return event.NewErrorEvent(e.Time(), fmt.Errorf("Service.ServeEvent: cant interpret event to PromotionEvent"))
+		}
+
+		if err := s.cc.Promote(promotionEvent.Time(), promotionEvent.Client(), promotionEvent.Promotion()); err != nil {
+			return event.NewErrorEvent(e.Time(), err)
+		}
+	case event.VoidEventId, event.AmendEventId:
+		if err := s.correct(e); err != nil {
+			return event.NewErrorEvent(e.Time(), err)
+		}
 	}
 	return event.EmptyEvent
 }
 
 func (s *Service) Close() ([]event.OutLeaveEvent, error) {
+	restoreLogger := s.logAs(event.OutLeaveEventId, s.cc.CloseTime)
 	clients, err := s.cc.Close()
+	restoreLogger()
 	if err != nil {
 		return nil, err
 	}
@@ -84,6 +196,10 @@ func (s *Service) Close() ([]event.OutLeaveEvent, error) {
 		return events[i].Client() <= events[j].Client()
 
 	})
+	// subscribers get copies, so that they don't share the events returned
+	for _, e := range events {
+		s.events.Publish(&e)
+	}
 	return events, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/bus"
 	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
 	"github.com/GerogeGol/yadro-test-problem/domain/service"
 	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
@@ -140,6 +141,29 @@ func TestWaitEvent(t *testing.T) {
 		test.AssertEqual(t, outLeaveEvent.Client(), newClient2)
 	})
 
+	t.Run("seated and waiting clients wait again on a full queue", func(t *testing.T) {
+		s := service.NewService(service.NewComputerClub(1, dummyMoneyPerHour, dummyOpenTime, dummyCloseTime, memstore.NewStore(), memqueue.NewQueue()))
+
+		events := []event.InputEvent{
+			event.NewArrivalEvent(dummyDayTime, "a"),
+			event.NewSitDownEvent(dummyDayTime, "a", dummyTableNumber),
+			event.NewArrivalEvent(dummyDayTime, "b"),
+			event.NewWaitEvent(dummyDayTime, "b"),
+			event.NewWaitEvent(dummyDayTime, "a"),
+			event.NewWaitEvent(dummyDayTime, "b"),
+		}
+		for _, e := range events {
+			gotEvent := s.ServeEvent(e)
+			assertNoErrorEvent(t, gotEvent)
+			assertEmptyEvent(t, gotEvent)
+		}
+
+		clients, err := s.Close()
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(clients), 2)
+		test.AssertEqual(t, clients[0].Client(), "a")
+		test.AssertEqual(t, clients[1].Client(), "b")
+	})
 }
 
 func TestLeaveEvent(t *testing.T) {
@@ -273,6 +297,47 @@ func TestServiceClose(t *testing.T) {
 	})
 }
 
+func TestServiceSubscribe(t *testing.T) {
+	t.Run("generated events are published", func(t *testing.T) {
+		s := service.NewService(dummyClub())
+		sub := s.Subscribe(10)
+		defer sub.Unsubscribe()
+
+		s.ServeEvent(event.NewArrivalEvent(dummyDayTime, dummyClient))
+		s.ServeEvent(event.NewArrivalEvent(dummyDayTime, dummyClient))
+		s.ServeEvent(event.NewSitDownEvent(dummyDayTime, dummyClient, dummyTableNumber))
+		_, err := s.Close()
+		test.AssertNoError(t, err)
+
+		test.AssertEqual(t, len(sub.C()), 2)
+		assertErrorEvent(t, <-sub.C(), service.YouShallNotPass)
+		test.AssertEqual(t, (<-sub.C()).Id(), event.OutLeaveEventId)
+	})
+
+	t.Run("close publishes copies of the events returned", func(t *testing.T) {
+		s := service.NewService(dummyClub())
+		sub := s.Subscribe(10)
+		defer sub.Unsubscribe()
+
+		s.ServeEvent(event.NewArrivalEvent(dummyDayTime, dummyClient))
+		events, err := s.Close()
+		test.AssertNoError(t, err)
+
+		published := <-sub.C()
+		test.AssertTrue(t, published != event.Event(&events[0]))
+		test.AssertEqual(t, published.(*event.OutLeaveEvent).Client(), events[0].Client())
+	})
+
+	t.Run("shared bus", func(t *testing.T) {
+		events := bus.New[event.Event]()
+		sub := events.Subscribe(1)
+		s := service.NewService(dummyClub(), service.WithEvents(events))
+
+		s.ServeEvent(event.NewLeaveEvent(dummyDayTime, dummyClient))
+		assertErrorEvent(t, <-sub.C(), service.ClientUnknown)
+	})
+}
+
 func TestServiceProfit(t *testing.T) {
 	t.Run("no clients seated", func(t *testing.T) {
 		tablesCount := 9
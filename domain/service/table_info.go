@@ -2,20 +2,43 @@ package service
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"time"
-
-	"github.com/GerogeGol/yadro-test-problem/domain/store"
 )
 
 type TableInfo struct {
-	Number      int
+	Number int
+	// Profit is the money taken, Discount the money the promotions took
+	// off.
 	Profit      float64
+	Discount    float64
 	WorkingTime time.Duration
 }
 
+// Gross returns what the table would have taken without the promotions.
+func (i TableInfo) Gross() float64 {
+	return i.Profit + i.Discount
+}
+
+// String prints the working time as HH:MM, the hours may go past 24 when
+// the clocks are put back during the club day.
 func (i TableInfo) String() string {
+	return fmt.Sprintf("%d %.f %s", i.Number, i.Profit, i.workingTime())
+}
+
+// Discounted prints the net profit, the working time, the gross and the
+// discount, the money to the cent.
+func (i TableInfo) Discounted() string {
+	return fmt.Sprintf("%d %s %s %s %s", i.Number, money(i.Profit), i.workingTime(), money(i.Gross()), money(i.Discount))
+}
+
+func (i TableInfo) workingTime() string {
 	hours := int(i.WorkingTime.Hours())
 	minutes := int(i.WorkingTime.Minutes()) - 60*hours
-	time := store.NewDayTime(hours, minutes)
-	return fmt.Sprintf("%d %.f %s", i.Number, i.Profit, time)
+	return fmt.Sprintf("%02d:%02d", hours, minutes)
+}
+
+func money(m float64) string {
+	return strconv.FormatFloat(math.Round(m*100)/100, 'f', -1, 64)
 }
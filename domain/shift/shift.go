@@ -0,0 +1,198 @@
+package shift
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+// Shift is the part of the club day worked by one operator, from Start
+// until the start of the next shift or the close time.
+type Shift struct {
+	Start    store.DayTime
+	Operator string
+}
+
+// Session is a client at a table at the handover.
+type Session struct {
+	Client string
+	Table  int
+	Since  store.DayTime
+	// Accrued is the hour cost of the time played so far, not rounded up to
+	// whole hours. The discount of the session isn't known before it is
+	// billed, so it is taken off by the shift that bills it.
+	Accrued float64
+}
+
+// Report is what an operator hands over at the end of the shift.
+type Report struct {
+	Operator string
+	Start    store.DayTime
+	End      store.DayTime
+	// Billed is the money taken from the clients who left within the shift.
+	Billed float64
+	// Revenue is the share of the shift in the sessions: the hour cost of
+	// the time played within the shift and the rounding up of the sessions
+	// billed within it, before the discounts. A session open at the
+	// handover is split this way between the shifts.
+	Revenue float64
+	// Discount is the money the promotions took off the sessions billed
+	// within the shift, Revenue less Discount is the net share of the shift.
+	// All three take in the corrections made within the shift, of any
+	// session.
+	Discount float64
+	Open     []Session
+	Queue    []string
+}
+
+// String prints the report as lines of the output: the shift, the money and
+// then what is handed over.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s\n", r.Start, r.End, r.Operator)
+	fmt.Fprintf(&b, "billed %.2f\n", r.Billed)
+	fmt.Fprintf(&b, "revenue %.2f\n", r.Revenue)
+	fmt.Fprintf(&b, "discount %.2f\n", r.Discount)
+	for _, s := range r.Open {
+		fmt.Fprintf(&b, "open %d %s %s %.2f\n", s.Table, s.Client, s.Since, s.Accrued)
+	}
+	fmt.Fprintf(&b, "queue %d", len(r.Queue))
+	for _, client := range r.Queue {
+		fmt.Fprintf(&b, " %s", client)
+	}
+	return b.String()
+}
+
+type session struct {
+	table int
+	since store.DayTime
+	// accrued is the money taken into the reports of the previous shifts.
+	accrued float64
+}
+
+// Ledger follows the domain events of the club and hands over the shifts.
+// A shift is handed over with the first event at or after the start of the
+// next one, the last shift when the club closes.
+type Ledger struct {
+	// Handover, if set, gets the report of every shift as it ends.
+	Handover func(r Report)
+	// HourCost, if set, prices the session at table started at since in
+	// place of the hour cost of the club, see service.ComputerClub.HourCost.
+	HourCost     func(table int, since store.DayTime) float64
+	moneyPerHour float64
+	shifts       []Shift
+	reports      []Report
+	sessions     map[string]*session
+	queue        []string
+	closed       bool
+}
+
+// NewLedger returns the ledger of the club day split into shifts, sorted by
+// their start. The first shift starts at openTime whatever its Start.
+func NewLedger(openTime store.DayTime, moneyPerHour float64, shifts []Shift) *Ledger {
+	l := &Ledger{moneyPerHour: moneyPerHour, shifts: shifts, sessions: map[string]*session{}}
+	operator := ""
+	if len(shifts) > 0 {
+		operator = shifts[0].Operator
+	}
+	l.reports = []Report{{Operator: operator, Start: openTime}}
+	return l
+}
+
+// Reports returns the reports of the shifts handed over so far.
+func (l *Ledger) Reports() []Report {
+	if l.closed {
+		return slices.Clone(l.reports)
+	}
+	return slices.Clone(l.reports[:len(l.reports)-1])
+}
+
+func (l *Ledger) Handle(e service.DomainEvent) {
+	if l.closed {
+		return
+	}
+	l.handOverUntil(e.At())
+
+	current := &l.reports[len(l.reports)-1]
+	switch e := e.(type) {
+	case service.ClientSeated:
+		l.sessions[e.Client] = &session{table: e.Table, since: e.Time}
+		l.queue = slices.DeleteFunc(l.queue, func(c string) bool { return c == e.Client })
+	case service.TableChanged:
+		if s, ok := l.sessions[e.Client]; ok {
+			s.table = e.Table
+		}
+	case service.ClientQueued:
+		l.queue = append(l.queue, e.Client)
+	case service.ClientLeft:
+		l.queue = slices.DeleteFunc(l.queue, func(c string) bool { return c == e.Client })
+	case service.SessionBilled:
+		current.Billed += e.Payment
+		current.Discount += e.Discount
+		if s, ok := l.sessions[e.Client]; ok {
+			current.Revenue += e.Gross - s.accrued
+			delete(l.sessions, e.Client)
+		} else {
+			current.Revenue += e.Gross
+		}
+	case service.EventCorrected:
+		// the shift of the correction takes back what the corrected events
+		// don't bill, whichever shift had it
+		for _, b := range e.Unbilled {
+			current.Billed -= b.Payment
+			current.Revenue -= b.Gross
+			current.Discount -= b.Discount
+		}
+		for _, b := range e.Billed {
+			current.Billed += b.Payment
+			current.Revenue += b.Gross
+			current.Discount += b.Discount
+		}
+	case service.ClubClosed:
+		current.End = e.Time
+		l.closed = true
+		l.handOver(*current)
+	}
+}
+
+// handOverUntil ends the shifts that started by t.
+func (l *Ledger) handOverUntil(t store.DayTime) {
+	for next := len(l.reports); next < len(l.shifts); next++ {
+		start := l.shifts[next].Start
+		if t.Compare(start.Time) == -1 {
+			return
+		}
+
+		current := &l.reports[len(l.reports)-1]
+		current.End = start
+		current.Queue = slices.Clone(l.queue)
+		for client, s := range l.sessions {
+			accrued := l.accrued(s.table, s.since, start)
+			current.Open = append(current.Open, Session{Client: client, Table: s.table, Since: s.since, Accrued: accrued})
+			current.Revenue += accrued - s.accrued
+			s.accrued = accrued
+		}
+		slices.SortFunc(current.Open, func(a, b Session) int { return a.Table - b.Table })
+		l.handOver(*current)
+		l.reports = append(l.reports, Report{Operator: l.shifts[next].Operator, Start: start})
+	}
+}
+
+// accrued is the hour cost of the time from since to t at table, before
+// the discount.
+func (l *Ledger) accrued(table int, since, t store.DayTime) float64 {
+	hourCost := l.moneyPerHour
+	if l.HourCost != nil {
+		hourCost = l.HourCost(table, since)
+	}
+	return hourCost * t.Sub(since.Time).Hours()
+}
+
+func (l *Ledger) handOver(r Report) {
+	if l.Handover != nil {
+		l.Handover(r)
+	}
+}
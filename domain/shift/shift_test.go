@@ -0,0 +1,195 @@
+package shift_test
+
+import (
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/shift"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestLedger(t *testing.T) {
+	shifts := []shift.Shift{
+		{Start: store.NewDayTime(9, 0), Operator: "anna"},
+		{Start: store.NewDayTime(16, 0), Operator: "boris"},
+	}
+	club := service.NewComputerClub(2, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	ledger := shift.NewLedger(club.OpenTime, club.MoneyPerHour, shifts)
+	var handedOver []shift.Report
+	ledger.Handover = func(r shift.Report) { handedOver = append(handedOver, r) }
+	club.DomainEvents().Handle(ledger.Handle)
+
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 0), "a", 1))
+	_, _, err := club.Leave(store.NewDayTime(11, 0), "a")
+	test.AssertNoError(t, err)
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(15, 0), "b"))
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(15, 0), "c"))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(15, 0), "c", 1))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(15, 30), "b", 2))
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(15, 40), "d"))
+	_, err = club.Wait(store.NewDayTime(15, 40), "d")
+	test.AssertNoError(t, err)
+
+	t.Run("the shift isn't handed over before a later event", func(t *testing.T) {
+		test.AssertEqual(t, len(ledger.Reports()), 0)
+	})
+
+	_, _, err = club.Leave(store.NewDayTime(16, 10), "b")
+	test.AssertNoError(t, err)
+
+	t.Run("handover", func(t *testing.T) {
+		test.AssertEqual(t, len(handedOver), 1)
+		r := handedOver[0]
+		test.AssertEqual(t, r.Operator, "anna")
+		test.AssertDayTime(t, r.Start, store.NewDayTime(9, 0))
+		test.AssertDayTime(t, r.End, store.NewDayTime(16, 0))
+		test.AssertEqual(t, r.Billed, 20)
+		test.AssertEqual(t, r.Revenue, 35)
+		test.AssertEqual(t, len(r.Open), 2)
+		test.AssertEqual(t, r.Open[0], shift.Session{Client: "c", Table: 1, Since: store.NewDayTime(15, 0), Accrued: 10})
+		test.AssertEqual(t, r.Open[1], shift.Session{Client: "b", Table: 2, Since: store.NewDayTime(15, 30), Accrued: 5})
+		test.AssertEqual(t, len(r.Queue), 1)
+		test.AssertEqual(t, r.Queue[0], "d")
+
+		want := `09:00 16:00 anna
+billed 20.00
+revenue 35.00
+discount 0.00
+open 1 c 15:00 10.00
+open 2 b 15:30 5.00
+queue 1 d`
+		if diff := test.LineDiff(want, r.String()); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	_, err = club.Close()
+	test.AssertNoError(t, err)
+
+	t.Run("close", func(t *testing.T) {
+		test.AssertEqual(t, len(handedOver), 2)
+		r := handedOver[1]
+		test.AssertEqual(t, r.Operator, "boris")
+		test.AssertDayTime(t, r.Start, store.NewDayTime(16, 0))
+		test.AssertDayTime(t, r.End, store.NewDayTime(19, 0))
+		test.AssertEqual(t, r.Billed, 80)
+		test.AssertEqual(t, r.Revenue, 65)
+		test.AssertEqual(t, len(r.Open), 0)
+		test.AssertEqual(t, len(r.Queue), 0)
+	})
+
+	t.Run("sessions are split between the shifts", func(t *testing.T) {
+		reports := ledger.Reports()
+		test.AssertEqual(t, len(reports), 2)
+		test.AssertEqual(t, reports[0].Revenue+reports[1].Revenue, reports[0].Billed+reports[1].Billed)
+	})
+}
+
+func TestLedgerDiscounts(t *testing.T) {
+	shifts := []shift.Shift{
+		{Start: store.NewDayTime(9, 0), Operator: "anna"},
+		{Start: store.NewDayTime(12, 0), Operator: "boris"},
+	}
+	club := service.NewComputerClub(1, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	ledger := shift.NewLedger(club.OpenTime, club.MoneyPerHour, shifts)
+	club.DomainEvents().Handle(ledger.Handle)
+
+	test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+	test.AssertNoError(t, club.Promote(store.NewDayTime(9, 0), "a", "-50%"))
+	test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 0), "a", 1))
+	// the session goes on through the handover
+	_, _, err := club.Leave(store.NewDayTime(13, 30), "a")
+	test.AssertNoError(t, err)
+	_, err = club.Close()
+	test.AssertNoError(t, err)
+
+	reports := ledger.Reports()
+	test.AssertEqual(t, len(reports), 2)
+	test.AssertEqual(t, reports[0].Revenue, 30)
+	test.AssertEqual(t, reports[0].Discount, 0)
+	test.AssertEqual(t, reports[1].Billed, 25)
+	test.AssertEqual(t, reports[1].Revenue, 20)
+	test.AssertEqual(t, reports[1].Discount, 25)
+
+	tables, err := club.TablesInfo()
+	test.AssertNoError(t, err)
+	test.AssertEqual(t, reports[0].Revenue+reports[1].Revenue, tables[0].Gross())
+	test.AssertEqual(t, reports[0].Discount+reports[1].Discount, tables[0].Discount)
+	test.AssertEqual(t, reports[0].Revenue+reports[1].Revenue-reports[0].Discount-reports[1].Discount, tables[0].Profit)
+}
+
+func TestLedgerCorrections(t *testing.T) {
+	shifts := []shift.Shift{
+		{Start: store.NewDayTime(9, 0), Operator: "anna"},
+		{Start: store.NewDayTime(12, 0), Operator: "boris"},
+	}
+	club := service.NewComputerClub(2, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	s := service.NewService(club, service.WithReplay(func() (store.Store, queue.Queue) {
+		return memstore.NewStore(), memqueue.NewQueue()
+	}))
+	ledger := shift.NewLedger(club.OpenTime, club.MoneyPerHour, shifts)
+	club.DomainEvents().Handle(ledger.Handle)
+
+	for _, e := range []event.InputEvent{
+		event.NewArrivalEvent(store.NewDayTime(9, 0), "a"),
+		event.NewSitDownEvent(store.NewDayTime(9, 0), "a", 1),
+		event.NewArrivalEvent(store.NewDayTime(10, 0), "b"),
+		event.NewSitDownEvent(store.NewDayTime(10, 0), "b", 2),
+		event.NewLeaveEvent(store.NewDayTime(11, 0), "a"),
+		event.NewLeaveEvent(store.NewDayTime(13, 0), "b"),
+		// the leave of a is voided after the handover, a plays till the close
+		event.NewVoidEvent(store.NewDayTime(13, 30), 5),
+	} {
+		if out := s.ServeEvent(e); out.Id() == event.ErrorEventId {
+			t.Fatalf("%v answered %v", e, out)
+		}
+	}
+	_, err := s.Close()
+	test.AssertNoError(t, err)
+
+	reports := ledger.Reports()
+	test.AssertEqual(t, len(reports), 2)
+	test.AssertEqual(t, reports[0].Billed, 20)
+	test.AssertEqual(t, reports[1].Billed, -20+30+100)
+
+	tables, err := s.Profit()
+	test.AssertNoError(t, err)
+	var profit, billed, revenue float64
+	for _, table := range tables {
+		profit += table.Profit
+	}
+	for _, r := range reports {
+		billed += r.Billed
+		revenue += r.Revenue
+	}
+	test.AssertEqual(t, profit, 130)
+	test.AssertEqual(t, billed, profit)
+	test.AssertEqual(t, revenue, profit)
+}
+
+func TestLedgerWithoutEvents(t *testing.T) {
+	shifts := []shift.Shift{
+		{Start: store.NewDayTime(9, 0), Operator: "anna"},
+		{Start: store.NewDayTime(13, 0), Operator: "boris"},
+		{Start: store.NewDayTime(16, 0), Operator: "vera"},
+	}
+	club := service.NewComputerClub(2, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+	ledger := shift.NewLedger(club.OpenTime, club.MoneyPerHour, shifts)
+	club.DomainEvents().Handle(ledger.Handle)
+
+	// every shift is handed over at the close even if nothing happened
+	_, err := club.Close()
+	test.AssertNoError(t, err)
+
+	reports := ledger.Reports()
+	test.AssertEqual(t, len(reports), 3)
+	test.AssertEqual(t, reports[1].Operator, "boris")
+	test.AssertDayTime(t, reports[1].End, store.NewDayTime(16, 0))
+	test.AssertDayTime(t, reports[2].End, store.NewDayTime(19, 0))
+}
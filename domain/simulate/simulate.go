@@ -0,0 +1,414 @@
+package simulate
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/queue"
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/service/event"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+)
+
+var IncorrectScenario = fmt.Errorf("incorrect scenario")
+
+// defaultSession is used as the wish of clients who never got a table when
+// the log has no finished sessions to take an average from.
+const defaultSession = time.Hour
+
+type Config struct {
+	Tables        int
+	QueueCapacity int
+	HourCost      float64
+	OpenTime      store.DayTime
+	CloseTime     store.DayTime
+}
+
+func ConfigFromLog(log scan.Log) Config {
+	return Config{
+		Tables:        log.TablesCount,
+		QueueCapacity: log.TablesCount,
+		HourCost:      log.HourCost,
+		OpenTime:      log.OpenTime,
+		CloseTime:     log.CloseTime,
+	}
+}
+
+// Scenario describes a what-if change of the club configuration. Zero values
+// keep the baseline settings.
+type Scenario struct {
+	Tables        int
+	QueueCapacity int
+	// PriceFactor multiplies the hour cost.
+	PriceFactor float64
+	// Elasticity is the price elasticity of demand: the number of arrivals is
+	// multiplied by PriceFactor^-Elasticity.
+	Elasticity float64
+	Seed       int64
+}
+
+func (s Scenario) Validate() error {
+	switch {
+	case s.Tables < 0:
+		return fmt.Errorf("Scenario.Validate: tables count could not be negative: %w", IncorrectScenario)
+	case s.QueueCapacity < 0:
+		return fmt.Errorf("Scenario.Validate: queue capacity could not be negative: %w", IncorrectScenario)
+	case s.PriceFactor < 0:
+		return fmt.Errorf("Scenario.Validate: price factor could not be negative: %w", IncorrectScenario)
+	case s.Elasticity < 0:
+		return fmt.Errorf("Scenario.Validate: elasticity could not be negative: %w", IncorrectScenario)
+	}
+	return nil
+}
+
+func (s Scenario) Apply(base Config) Config {
+	cfg := base
+	if s.Tables != 0 {
+		cfg.Tables = s.Tables
+		cfg.QueueCapacity = s.Tables
+	}
+	if s.QueueCapacity != 0 {
+		cfg.QueueCapacity = s.QueueCapacity
+	}
+	if s.PriceFactor != 0 {
+		cfg.HourCost *= s.PriceFactor
+	}
+	return cfg
+}
+
+// Visit is a single client's demand extracted from a log: when they came, how
+// long they wanted to play and whether they were ready to wait for a table.
+type Visit struct {
+	Client  string
+	Arrival store.DayTime
+	Session time.Duration
+	Patient bool
+}
+
+// Demand replays the log through a ComputerClub and turns it into visits.
+// Clients who never got a table are assumed to want an average session.
+// The void and amend events are applied first, the way the club serves
+// them, so the visits are the ones the corrected log tells about.
+func Demand(log scan.Log) ([]Visit, error) {
+	cfg := ConfigFromLog(log)
+	club := newClub(cfg)
+
+	events := log.Events
+	if slices.ContainsFunc(events, func(e event.InputEvent) bool { return event.IsCorrection(e) }) {
+		s := service.NewService(newClub(cfg), service.WithReplay(func() (store.Store, queue.Queue) {
+			return memstore.NewStore(), memqueue.NewQueue()
+		}))
+		for _, e := range events {
+			s.ServeEvent(e)
+		}
+		events = s.Corrected()
+	}
+
+	type visit struct {
+		Visit
+		seated   bool
+		seatedAt store.DayTime
+		left     store.DayTime
+	}
+	present := map[string]*visit{}
+	var visits []*visit
+
+	for _, e := range events {
+		t, name := e.Time(), e.Client()
+
+		switch e := e.(type) {
+		case *event.ArriveEvent:
+			if err := club.Arrive(t, name); err != nil {
+				continue
+			}
+			v := &visit{Visit: Visit{Client: name, Arrival: t}}
+			present[name] = v
+			visits = append(visits, v)
+		case *event.SitDownEvent:
+			err := club.SitDown(t, name, e.Table())
+			if err != nil && !service.IsRecoverable(err) {
+				return nil, fmt.Errorf("simulate.Demand: %w", err)
+			}
+			if v := present[name]; err == nil && !v.seated {
+				v.seated, v.seatedAt = true, t
+			}
+		case *event.WaitEvent:
+			waiting, err := club.Wait(t, name)
+			v, ok := present[name]
+			if !ok || (err != nil && err != service.ICanWaitNoLonger) {
+				continue
+			}
+			v.Patient = true
+			if err == nil && !waiting {
+				// the queue is full and the club sent the client away
+				v.left = t
+				delete(present, name)
+			}
+		case *event.LeaveEvent:
+			seated, occupied, err := club.Leave(t, name)
+			if err != nil {
+				continue
+			}
+			present[name].left = t
+			delete(present, name)
+			if occupied {
+				v := present[seated.Name]
+				v.seated, v.seatedAt = true, t
+			}
+		}
+	}
+	for _, v := range present {
+		v.left = cfg.CloseTime
+	}
+
+	var total time.Duration
+	var sessions int
+	for _, v := range visits {
+		if v.seated {
+			v.Session = v.left.Sub(v.seatedAt.Time)
+			total += v.Session
+			sessions++
+		}
+	}
+
+	average := defaultSession
+	if sessions != 0 {
+		average = total / time.Duration(sessions)
+	}
+
+	result := make([]Visit, 0, len(visits))
+	for _, v := range visits {
+		if !v.seated {
+			v.Session = average
+		}
+		result = append(result, v.Visit)
+	}
+	return result, nil
+}
+
+// ElasticDemand scales the number of visits by priceFactor^-elasticity. Each
+// visit is kept, dropped or repeated at random, so the result depends on seed.
+func ElasticDemand(visits []Visit, priceFactor, elasticity float64, seed int64) []Visit {
+	if priceFactor == 0 || priceFactor == 1 || elasticity == 0 {
+		return visits
+	}
+
+	rnd := rand.New(rand.NewSource(seed))
+	multiplier := math.Pow(priceFactor, -elasticity)
+	whole, frac := math.Modf(multiplier)
+
+	var scaled []Visit
+	for _, v := range visits {
+		copies := int(whole)
+		if rnd.Float64() < frac {
+			copies++
+		}
+		for i := 0; i < copies; i++ {
+			c := v
+			if i > 0 {
+				c.Client = fmt.Sprintf("%s_%d", v.Client, i+1)
+			}
+			scaled = append(scaled, c)
+		}
+	}
+	return scaled
+}
+
+type Result struct {
+	Revenue     float64
+	Served      int
+	Refusals    int
+	AverageWait time.Duration
+	Utilisation float64
+}
+
+// Run plays the visits against a ComputerClub built from cfg. A client takes
+// the free table with the lowest number, waits if they are patient and there
+// is room in the queue, and is refused otherwise. Clients still waiting at
+// CloseTime are refused too.
+func Run(cfg Config, visits []Visit) (Result, error) {
+	club := newClub(cfg)
+	byName := map[string]Visit{}
+	seated := map[string]bool{}
+	leaving := queue.NewDepartures(func(a, b store.DayTime) bool { return a.Before(b.Time) })
+	var result Result
+	var totalWait time.Duration
+
+	seat := func(t store.DayTime, v Visit) {
+		seated[v.Client] = true
+		totalWait += t.Sub(v.Arrival.Time)
+		result.Served++
+		leaving.Push(store.DayTime{Time: t.Add(v.Session)}, v.Client)
+	}
+
+	leaveUntil := func(t store.DayTime) error {
+		for leaving.Len() > 0 {
+			if at, _ := leaving.Next(); at.Compare(t.Time) > 0 || at.Compare(cfg.CloseTime.Time) >= 0 {
+				return nil
+			}
+			at, client := leaving.Pop()
+			next, occupied, err := club.Leave(at, client)
+			if err != nil {
+				return err
+			}
+			if occupied {
+				seat(at, byName[next.Name])
+			}
+		}
+		return nil
+	}
+
+	for _, v := range visits {
+		if err := leaveUntil(v.Arrival); err != nil {
+			return result, fmt.Errorf("simulate.Run: %w", err)
+		}
+
+		byName[v.Client] = v
+		if err := club.Arrive(v.Arrival, v.Client); err != nil {
+			result.Refusals++
+			continue
+		}
+
+		if table, ok, err := freeTable(club); err != nil {
+			return result, fmt.Errorf("simulate.Run: %w", err)
+		} else if ok {
+			if err := club.SitDown(v.Arrival, v.Client, table); err != nil {
+				return result, fmt.Errorf("simulate.Run: %w", err)
+			}
+			seat(v.Arrival, v)
+			continue
+		}
+
+		if v.Patient {
+			waiting, err := club.Wait(v.Arrival, v.Client)
+			if err != nil {
+				return result, fmt.Errorf("simulate.Run: %w", err)
+			}
+			if !waiting {
+				result.Refusals++
+			}
+			continue
+		}
+
+		result.Refusals++
+		if _, _, err := club.Leave(v.Arrival, v.Client); err != nil {
+			return result, fmt.Errorf("simulate.Run: %w", err)
+		}
+	}
+
+	if err := leaveUntil(cfg.CloseTime); err != nil {
+		return result, fmt.Errorf("simulate.Run: %w", err)
+	}
+
+	left, err := club.Close()
+	if err != nil {
+		return result, fmt.Errorf("simulate.Run: %w", err)
+	}
+	for _, c := range left {
+		if !seated[c.Name] {
+			result.Refusals++
+		}
+	}
+
+	tables, err := club.TablesInfo()
+	if err != nil {
+		return result, fmt.Errorf("simulate.Run: %w", err)
+	}
+	var busy time.Duration
+	for _, table := range tables {
+		result.Revenue += table.Profit
+		busy += table.WorkingTime
+	}
+
+	open := cfg.CloseTime.Sub(cfg.OpenTime.Time)
+	if open > 0 {
+		result.Utilisation = float64(busy) / float64(open*time.Duration(cfg.Tables))
+	}
+	if result.Served != 0 {
+		result.AverageWait = totalWait / time.Duration(result.Served)
+	}
+	return result, nil
+}
+
+type Comparison struct {
+	Baseline Result
+	WhatIf   Result
+}
+
+// WhatIf runs the demand of the log both under its own configuration and
+// under the scenario, so that both results come from the same model.
+func WhatIf(log scan.Log, s Scenario) (Comparison, error) {
+	if err := s.Validate(); err != nil {
+		return Comparison{}, fmt.Errorf("simulate.WhatIf: %w", err)
+	}
+
+	visits, err := Demand(log)
+	if err != nil {
+		return Comparison{}, fmt.Errorf("simulate.WhatIf: %w", err)
+	}
+
+	base := ConfigFromLog(log)
+	baseline, err := Run(base, visits)
+	if err != nil {
+		return Comparison{}, fmt.Errorf("simulate.WhatIf: %w", err)
+	}
+
+	whatIf, err := Run(s.Apply(base), ElasticDemand(visits, s.PriceFactor, s.Elasticity, s.Seed))
+	if err != nil {
+		return Comparison{}, fmt.Errorf("simulate.WhatIf: %w", err)
+	}
+	return Comparison{Baseline: baseline, WhatIf: whatIf}, nil
+}
+
+func (c Comparison) String() string {
+	b, w := c.Baseline, c.WhatIf
+	buf := &strings.Builder{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintln(tw, "\tbaseline\twhat-if\tdelta\t")
+	fmt.Fprintf(tw, "revenue\t%.f\t%.f\t%+.f\t\n", b.Revenue, w.Revenue, w.Revenue-b.Revenue)
+	fmt.Fprintf(tw, "served\t%d\t%d\t%+d\t\n", b.Served, w.Served, w.Served-b.Served)
+	fmt.Fprintf(tw, "refusals\t%d\t%d\t%+d\t\n", b.Refusals, w.Refusals, w.Refusals-b.Refusals)
+	fmt.Fprintf(tw, "average wait\t%s\t%s\t%s\t\n", minutes(b.AverageWait), minutes(w.AverageWait), signedMinutes(w.AverageWait-b.AverageWait))
+	fmt.Fprintf(tw, "utilisation\t%.1f%%\t%.1f%%\t%+.1fpp\t\n", 100*b.Utilisation, 100*w.Utilisation, 100*(w.Utilisation-b.Utilisation))
+	tw.Flush()
+	return buf.String()
+}
+
+func minutes(d time.Duration) string {
+	return d.Round(time.Minute).String()
+}
+
+func signedMinutes(d time.Duration) string {
+	if d < 0 {
+		return minutes(d)
+	}
+	return "+" + minutes(d)
+}
+
+func newClub(cfg Config) *service.ComputerClub {
+	club := service.NewComputerClub(cfg.Tables, cfg.HourCost, cfg.OpenTime, cfg.CloseTime, memstore.NewStore(), memqueue.NewQueue())
+	club.QueueCapacity = cfg.QueueCapacity
+	return club
+}
+
+func freeTable(club *service.ComputerClub) (int, bool, error) {
+	for i := 1; i <= club.ComputerCount; i++ {
+		table, err := club.Info(i)
+		if err != nil {
+			return 0, false, err
+		}
+		if !table.IsBusy {
+			return i, true, nil
+		}
+	}
+	return 0, false, nil
+}
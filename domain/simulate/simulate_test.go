@@ -0,0 +1,120 @@
+package simulate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/generate"
+	"github.com/GerogeGol/yadro-test-problem/domain/scan"
+	"github.com/GerogeGol/yadro-test-problem/domain/simulate"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestDemand(t *testing.T) {
+	t.Run("visits are taken from a historic log", func(t *testing.T) {
+		log := readLog(t, `1
+09:00 19:00
+10
+09:00 1 first
+09:00 2 first 1
+09:30 1 second
+09:30 3 second
+10:00 1 third
+10:00 4 third
+11:00 4 first
+`)
+		visits, err := simulate.Demand(log)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(visits), 3)
+
+		test.AssertEqual(t, visits[0].Session, store.NewDayTime(11, 0).Sub(store.NewDayTime(9, 0).Time))
+		test.AssertFalse(t, visits[0].Patient)
+
+		test.AssertEqual(t, visits[1].Session, store.NewDayTime(19, 0).Sub(store.NewDayTime(11, 0).Time))
+		test.AssertTrue(t, visits[1].Patient)
+
+		test.AssertFalse(t, visits[2].Patient)
+	})
+
+	t.Run("corrections are applied", func(t *testing.T) {
+		log := readLog(t, `1
+09:00 19:00
+10
+09:00 1 first
+09:00 2 first 1
+10:00 1 second
+10:00 3 second
+11:00 4 first
+11:00 5 5
+12:00 6 3 third
+`)
+		visits, err := simulate.Demand(log)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(visits), 2)
+
+		// the leave is voided, the first client stays until the close
+		test.AssertEqual(t, visits[0].Session, store.NewDayTime(19, 0).Sub(store.NewDayTime(9, 0).Time))
+		// the arrival is amended, the wait of the second client is unknown
+		test.AssertEqual(t, visits[1].Client, "third")
+		test.AssertFalse(t, visits[1].Patient)
+	})
+}
+
+func TestWhatIf(t *testing.T) {
+	log := generatedLog(t)
+
+	t.Run("same config gives same result", func(t *testing.T) {
+		c, err := simulate.WhatIf(log, simulate.Scenario{})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, c.Baseline, c.WhatIf)
+	})
+
+	t.Run("more tables never refuse more clients", func(t *testing.T) {
+		c, err := simulate.WhatIf(log, simulate.Scenario{Tables: log.TablesCount + 2})
+		test.AssertNoError(t, err)
+		test.AssertTrue(t, c.WhatIf.Refusals <= c.Baseline.Refusals)
+		test.AssertTrue(t, c.WhatIf.Revenue >= c.Baseline.Revenue)
+	})
+
+	t.Run("inelastic price rise scales revenue", func(t *testing.T) {
+		c, err := simulate.WhatIf(log, simulate.Scenario{PriceFactor: 2})
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, c.WhatIf.Revenue, 2*c.Baseline.Revenue)
+		test.AssertEqual(t, c.WhatIf.Served, c.Baseline.Served)
+	})
+
+	t.Run("elastic demand is reproducible by seed", func(t *testing.T) {
+		s := simulate.Scenario{PriceFactor: 1.2, Elasticity: 1.5, Seed: 7}
+		first, err := simulate.WhatIf(log, s)
+		test.AssertNoError(t, err)
+		second, err := simulate.WhatIf(log, s)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, first, second)
+	})
+
+	t.Run("incorrect scenario", func(t *testing.T) {
+		_, err := simulate.WhatIf(log, simulate.Scenario{Elasticity: -1})
+		test.AssertError(t, err, simulate.IncorrectScenario)
+	})
+}
+
+func generatedLog(t testing.TB) scan.Log {
+	t.Helper()
+	cfg := generate.DefaultConfig()
+	cfg.ArrivalRate = 10
+	cfg.Seed = 1
+
+	buf := &strings.Builder{}
+	test.AssertNoError(t, generate.Generate(buf, cfg))
+	return readLog(t, buf.String())
+}
+
+func readLog(t testing.TB, s string) scan.Log {
+	t.Helper()
+	log, line, err := scan.ReadLog(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("can't read log at line %q: %q", line, err)
+	}
+	return log
+}
@@ -3,12 +3,17 @@ package store
 import (
 	"math"
 	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 )
 
 type Client struct {
 	Name         string
 	Table        int
 	PlayingSince DayTime
+	// Promotion is the promotion applied when the client pays, nil for
+	// none.
+	Promotion promo.Promotion
 }
 
 func (c *Client) PlayingTime(t DayTime) time.Duration {
@@ -16,7 +21,11 @@ func (c *Client) PlayingTime(t DayTime) time.Duration {
 }
 
 func (c *Client) Payment(t DayTime, moneyPerHour float64) float64 {
-	playingTime := c.PlayingTime(t)
-	playedHours := math.Ceil(playingTime.Hours())
-	return float64(playedHours) * moneyPerHour
+	return float64(c.PlayedHours(t)) * moneyPerHour
+}
+
+// PlayedHours returns the hours the client pays for, begun hours count as
+// whole.
+func (c *Client) PlayedHours(t DayTime) int {
+	return int(math.Ceil(c.PlayingTime(t).Hours()))
 }
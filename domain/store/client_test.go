@@ -2,6 +2,7 @@ package store_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
 	"github.com/GerogeGol/yadro-test-problem/domain/test"
@@ -15,4 +16,72 @@ func TestClient(t *testing.T) {
 		payment := client.Payment(end, 1)
 		test.AssertEqual(t, payment, 10)
 	})
+
+	t.Run("a second over the hour is a started hour", func(t *testing.T) {
+		client := store.Client{PlayingSince: store.NewDayTimeSeconds(9, 0, 0)}
+
+		test.AssertEqual(t, client.Payment(store.NewDayTimeSeconds(10, 0, 0), 1), 1)
+		test.AssertEqual(t, client.Payment(store.NewDayTimeSeconds(10, 0, 1), 1), 2)
+		test.AssertEqual(t, client.PlayingTime(store.NewDayTimeSeconds(10, 0, 1)), time.Hour+time.Second)
+	})
+}
+
+func TestDayTime(t *testing.T) {
+	test.AssertEqual(t, store.NewDayTime(9, 5).String(), "09:05")
+	test.AssertEqual(t, store.NewDayTimeSeconds(9, 5, 7).String(), "09:05:07")
+}
+
+func TestClock(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	test.AssertNoError(t, err)
+
+	t.Run("zero clock keeps the time of day", func(t *testing.T) {
+		d, err := store.Clock{}.At(store.NewDayTime(9, 0))
+		test.AssertNoError(t, err)
+		test.AssertDayTime(t, d, store.NewDayTime(9, 0))
+	})
+
+	t.Run("spring forward", func(t *testing.T) {
+		clock := store.Clock{Day: time.Date(2024, 3, 10, 0, 0, 0, 0, newYork)}
+		before, err := clock.At(store.NewDayTime(1, 30))
+		test.AssertNoError(t, err)
+		after, err := clock.At(store.NewDayTime(3, 30))
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, after.Sub(before.Time), time.Hour)
+		test.AssertEqual(t, after.String(), "03:30")
+
+		_, err = clock.At(store.NewDayTime(2, 30))
+		test.AssertError(t, err, store.NonexistentTime)
+	})
+
+	t.Run("fall back", func(t *testing.T) {
+		clock := store.Clock{Day: time.Date(2024, 11, 3, 0, 0, 0, 0, newYork)}
+		before, err := clock.At(store.NewDayTime(0, 30))
+		test.AssertNoError(t, err)
+		after, err := clock.At(store.NewDayTime(2, 30))
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, after.Sub(before.Time), 3*time.Hour)
+	})
+
+	t.Run("overnight", func(t *testing.T) {
+		clock := store.Clock{Day: time.Date(2024, 11, 2, 0, 0, 0, 0, newYork), Overnight: true, NextDay: store.NewDayTime(6, 0)}
+		evening, err := clock.At(store.NewDayTime(22, 0))
+		test.AssertNoError(t, err)
+		morning, err := clock.At(store.NewDayTime(6, 0))
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, morning.Day(), 3)
+		test.AssertEqual(t, morning.Sub(evening.Time), 9*time.Hour)
+	})
+
+	t.Run("moment on the club day", func(t *testing.T) {
+		clock := store.Clock{Day: time.Date(2024, 11, 2, 0, 0, 0, 0, newYork), Overnight: true, NextDay: store.NewDayTime(6, 0)}
+		d, err := clock.On(time.Date(2024, 11, 3, 9, 0, 0, 0, time.UTC))
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, d.String(), "04:00:00")
+
+		_, err = clock.On(time.Date(2024, 11, 2, 7, 0, 0, 0, time.UTC))
+		test.AssertError(t, err, store.OtherDay)
+		_, err = clock.On(time.Date(2024, 11, 3, 16, 0, 0, 0, time.UTC))
+		test.AssertError(t, err, store.OtherDay)
+	})
 }
@@ -1,13 +1,108 @@
 package store
 
-import "time"
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
 
-type DayTime struct{ time.Time }
+type DayTime struct {
+	time.Time
+	// Seconds makes String print the seconds, for times given with them.
+	Seconds bool
+}
 
 func NewDayTime(hour int, minute int) DayTime {
-	return DayTime{time.Date(1, 1, 1, hour, minute, 0, 0, time.UTC)}
+	return DayTime{Time: time.Date(1, 1, 1, hour, minute, 0, 0, time.UTC)}
+}
+
+func NewDayTimeSeconds(hour int, minute int, second int) DayTime {
+	return DayTime{Time: time.Date(1, 1, 1, hour, minute, second, 0, time.UTC), Seconds: true}
 }
 
 func (d DayTime) String() string {
+	if d.Seconds {
+		return d.Time.Format("15:04:05")
+	}
 	return d.Time.Format("15:04")
 }
+
+// Equal reports whether d and u are the same moment, whether or not they
+// are printed with the seconds. Compare them with it rather than ==.
+func (d DayTime) Equal(u DayTime) bool {
+	return d.Time.Equal(u.Time)
+}
+
+// LogValue logs the time as the protocol prints it rather than the fake date.
+func (d DayTime) LogValue() slog.Value {
+	return slog.StringValue(d.String())
+}
+
+var NonexistentTime = fmt.Errorf("time of day is skipped by the daylight saving time change")
+var OtherDay = fmt.Errorf("time is not on the club day")
+
+// Clock puts times of day on the calendar of a club day, so that durations
+// are the real time passed across DST changes and midnight. The zero value
+// keeps times of day on a fake date in UTC.
+type Clock struct {
+	// Day is the midnight the club day starts at, in the club time zone.
+	Day time.Time
+	// Overnight tells that the club closes the next day: times of day up to
+	// and including NextDay belong to it.
+	Overnight bool
+	NextDay   DayTime
+}
+
+func (c Clock) IsZero() bool {
+	return c.Day.IsZero()
+}
+
+func (c Clock) Location() *time.Location {
+	if c.IsZero() {
+		return time.UTC
+	}
+	return c.Day.Location()
+}
+
+// At returns the moment of the club day at the time of day d. A time of day
+// that doesn't exist in the zone, because the clocks are put forward over
+// it, is an error. A time of day that happens twice when the clocks are put
+// back is the first one.
+func (c Clock) At(d DayTime) (DayTime, error) {
+	if c.IsZero() {
+		return d, nil
+	}
+	days := 0
+	if c.Overnight && wallClock(d) <= wallClock(c.NextDay) {
+		days = 1
+	}
+	year, month, day := c.Day.Date()
+	t := time.Date(year, month, day+days, d.Hour(), d.Minute(), d.Second(), 0, c.Location())
+	if t.Hour() != d.Hour() || t.Minute() != d.Minute() {
+		return d, NonexistentTime
+	}
+	return DayTime{Time: t, Seconds: d.Seconds}, nil
+}
+
+// On returns the moment t in the club time zone. A moment outside the club
+// day is an error.
+func (c Clock) On(t time.Time) (DayTime, error) {
+	d := DayTime{Time: t.In(c.Location()), Seconds: true}
+	if c.IsZero() {
+		return d, nil
+	}
+	days := 0
+	if c.Overnight && wallClock(d) <= wallClock(c.NextDay) {
+		days = 1
+	}
+	year, month, day := c.Day.Date()
+	wantYear, wantMonth, wantDay := time.Date(year, month, day+days, 0, 0, 0, 0, c.Location()).Date()
+	if year, month, day = d.Date(); year != wantYear || month != wantMonth || day != wantDay {
+		return d, OtherDay
+	}
+	return d, nil
+}
+
+func wallClock(d DayTime) time.Duration {
+	return time.Duration(d.Hour())*time.Hour + time.Duration(d.Minute())*time.Minute + time.Duration(d.Second())*time.Second
+}
@@ -2,30 +2,56 @@ package memstore
 
 import (
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/GerogeGol/yadro-test-problem/domain/logging"
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 	"github.com/GerogeGol/yadro-test-problem/domain/store"
 )
 
+// MemoryStore keeps the club day in memory. The loyalty points too, they
+// outlive the clients but not the store, so every run of the club starts
+// them from zero.
 type MemoryStore struct {
 	clients map[string]store.Client
 	tables  map[int]store.Table
+	points  map[string]int
+	logger  *slog.Logger
 }
 
-func NewStore() *MemoryStore {
-	return &MemoryStore{
+// Option configures a MemoryStore.
+type Option func(*MemoryStore)
+
+// WithLogger makes the store log every change at the debug level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *MemoryStore) {
+		m.logger = logger
+	}
+}
+
+func NewStore(opts ...Option) *MemoryStore {
+	m := &MemoryStore{
 		clients: map[string]store.Client{},
 		tables:  map[int]store.Table{},
+		points:  map[string]int{},
+		logger:  logging.Discard(),
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
 }
 
 func (m *MemoryStore) AddClient(clientName string) error {
 	m.clients[clientName] = store.Client{Name: clientName}
+	m.logger.Debug("client added", "client", clientName)
 	return nil
 }
 
 func (m *MemoryStore) RemoveClient(clientName string) error {
 	delete(m.clients, clientName)
+	m.logger.Debug("client removed", "client", clientName)
 	return nil
 }
 
@@ -42,6 +68,7 @@ func (m *MemoryStore) UpdateClientTable(clientName string, tableNumber int) erro
 	client := m.clients[clientName]
 	client.Table = tableNumber
 	m.clients[clientName] = client
+	m.logger.Debug("client table updated", "client", clientName, "table", tableNumber)
 	return nil
 }
 
@@ -56,6 +83,28 @@ func (m *MemoryStore) UpdateClientPlayingSince(clientName string, t store.DayTim
 	return nil
 }
 
+func (m *MemoryStore) UpdateClientPromotion(clientName string, promotion promo.Promotion) error {
+	if exists, _ := m.IsClientExists(clientName); !exists {
+		return fmt.Errorf("MemoryStore.UpdateClientPromotion: %w", store.ClientDoesNotExist)
+	}
+
+	client := m.clients[clientName]
+	client.Promotion = promotion
+	m.clients[clientName] = client
+	m.logger.Debug("client promotion updated", "client", clientName, "promotion", promotion)
+	return nil
+}
+
+func (m *MemoryStore) Points(clientName string) (int, error) {
+	return m.points[clientName], nil
+}
+
+func (m *MemoryStore) UpdatePoints(clientName string, points int) error {
+	m.points[clientName] = points
+	m.logger.Debug("client points updated", "client", clientName, "points", points)
+	return nil
+}
+
 func (m *MemoryStore) Client(clientName string) (client store.Client, err error) {
 	client, ok := m.clients[clientName]
 	if !ok {
@@ -85,6 +134,7 @@ func (m *MemoryStore) UpdateTableBusy(tableNumber int, isBusy bool) error {
 	table := m.tables[tableNumber]
 	table.IsBusy = isBusy
 	m.tables[tableNumber] = table
+	m.logger.Debug("table busy updated", "table", tableNumber, "busy", isBusy)
 	return nil
 }
 
@@ -99,5 +149,14 @@ func (m *MemoryStore) UpdateTableProfit(tableNumber int, newProfit float64) erro
 	table := m.tables[tableNumber]
 	table.Profit = newProfit
 	m.tables[tableNumber] = table
+	m.logger.Debug("table profit updated", "table", tableNumber, "profit", newProfit)
+	return nil
+}
+
+func (m *MemoryStore) UpdateTableDiscount(tableNumber int, newDiscount float64) error {
+	table := m.tables[tableNumber]
+	table.Discount = newDiscount
+	m.tables[tableNumber] = table
+	m.logger.Debug("table discount updated", "table", tableNumber, "discount", newDiscount)
 	return nil
 }
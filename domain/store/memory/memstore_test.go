@@ -1,6 +1,8 @@
 package memstore_test
 
 import (
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 
@@ -72,7 +74,7 @@ func TestUpdateClientPlayingSince(t *testing.T) {
 
 		client, err := m.Client(dummyClient)
 		test.AssertNoError(t, err)
-		test.AssertEqual(t, client.PlayingSince, playingSince)
+		test.AssertDayTime(t, client.PlayingSince, playingSince)
 	})
 	t.Run("update client table, but client does not exists. Should return ClientDoesNotExists error", func(t *testing.T) {
 		m := memstore.NewStore()
@@ -122,3 +124,40 @@ func TestUpdateTableProfit(t *testing.T) {
 		test.AssertEqual(t, table.Profit, addProfit)
 	})
 }
+
+func TestPoints(t *testing.T) {
+	t.Run("kept after the client leaves", func(t *testing.T) {
+		m := memstore.NewStore()
+		_ = m.AddClient(dummyClient)
+		_ = m.UpdatePoints(dummyClient, 12)
+		_ = m.RemoveClient(dummyClient)
+
+		points, err := m.Points(dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, points, 12)
+	})
+
+	t.Run("not kept past the store", func(t *testing.T) {
+		m := memstore.NewStore()
+		_ = m.UpdatePoints(dummyClient, 12)
+
+		points, err := memstore.NewStore().Points(dummyClient)
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, points, 0)
+	})
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("changes are logged at the debug level", func(t *testing.T) {
+		buf := &strings.Builder{}
+		m := memstore.NewStore(memstore.WithLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+
+		_ = m.AddClient(dummyClient)
+		_ = m.UpdateClientTable(dummyClient, dummyTableNumber)
+		_ = m.UpdateTableDiscount(dummyTableNumber, 2.5)
+
+		test.AssertTrue(t, strings.Contains(buf.String(), "level=DEBUG msg=\"client added\" client="+dummyClient))
+		test.AssertTrue(t, strings.Contains(buf.String(), "msg=\"client table updated\" client="+dummyClient+" table=1"))
+		test.AssertTrue(t, strings.Contains(buf.String(), "msg=\"table discount updated\" table=1 discount=2.5"))
+	})
+}
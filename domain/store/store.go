@@ -3,6 +3,8 @@ package store
 import (
 	"fmt"
 	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/promo"
 )
 
 var ClientDoesNotExist = fmt.Errorf("ClientDoesNotExist")
@@ -12,13 +14,19 @@ type Store interface {
 	IsClientExists(clientName string) (bool, error)
 	UpdateClientTable(clientName string, tableNumber int) error
 	UpdateClientPlayingSince(clientName string, t DayTime) error
+	UpdateClientPromotion(clientName string, promotion promo.Promotion) error
 	Client(clientName string) (Client, error)
 	Clients() ([]Client, error)
 	RemoveClient(clientName string) error
+	// Points are the loyalty points of a client, kept after the client
+	// leaves for as long as the store is.
+	Points(clientName string) (int, error)
+	UpdatePoints(clientName string, points int) error
 
 	Table(tableNumber int) (Table, error)
 	IsTableBusy(tableNumber int) (bool, error)
 	UpdateTableBusy(tableNumber int, isBusy bool) error
 	UpdateTableWorkingTime(tableNumber int, workingTime time.Duration) error
 	UpdateTableProfit(tableNumber int, profit float64) error
+	UpdateTableDiscount(tableNumber int, discount float64) error
 }
@@ -5,7 +5,9 @@ import "time"
 type Table struct {
 	IsBusy      bool
 	WorkingTime time.Duration
-	Profit      float64
+	// Profit is the money taken, after the discounts.
+	Profit   float64
+	Discount float64
 }
 
 func (t *Table) AddWorkingTime(d time.Duration) {
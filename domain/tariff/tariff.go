@@ -0,0 +1,79 @@
+package tariff
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+)
+
+// Class is a kind of tables with an hour cost of its own, like the tables
+// of a VIP room.
+type Class struct {
+	Name     string
+	Tables   []int
+	HourCost float64
+}
+
+// Tariff is the hour cost of the sessions started from From on, at the
+// tables of Class, or at the tables out of any class if Class is empty.
+type Tariff struct {
+	From     store.DayTime
+	Class    string
+	HourCost float64
+}
+
+// Plan prices the tables of a club. A session is billed at the hour cost of
+// the table it ends at, under the tariff in effect when it started: the
+// tariff of the table class with the latest From up to the start, otherwise
+// the hour cost of the class. The zero Plan bills every table at the hour
+// cost of the club.
+type Plan struct {
+	Classes []Class
+	Tariffs []Tariff
+}
+
+func (p Plan) IsZero() bool {
+	return len(p.Classes) == 0 && len(p.Tariffs) == 0
+}
+
+// HourCost returns the hour cost of a session at table started at since,
+// base for a table out of any class without a tariff.
+func (p Plan) HourCost(base float64, table int, since store.DayTime) float64 {
+	class, cost := "", base
+	if i := slices.IndexFunc(p.Classes, func(c Class) bool { return slices.Contains(c.Tables, table) }); i >= 0 {
+		class, cost = p.Classes[i].Name, p.Classes[i].HourCost
+	}
+
+	var latest *Tariff
+	for i, t := range p.Tariffs {
+		if t.Class != class || t.From.Compare(since.Time) == 1 {
+			continue
+		}
+		if latest == nil || t.From.Compare(latest.From.Time) >= 0 {
+			latest = &p.Tariffs[i]
+		}
+	}
+	if latest != nil {
+		cost = latest.HourCost
+	}
+	return cost
+}
+
+// On returns the plan with the starts of the tariffs put on the club day of
+// clock.
+func (p Plan) On(clock store.Clock) (Plan, error) {
+	if len(p.Tariffs) == 0 {
+		return p, nil
+	}
+	tariffs := make([]Tariff, len(p.Tariffs))
+	for i, t := range p.Tariffs {
+		from, err := clock.At(t.From)
+		if err != nil {
+			return p, fmt.Errorf("Plan.On: tariff from %s: %w", t.From, err)
+		}
+		t.From = from
+		tariffs[i] = t
+	}
+	return Plan{Classes: p.Classes, Tariffs: tariffs}, nil
+}
@@ -0,0 +1,43 @@
+package tariff_test
+
+import (
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	"github.com/GerogeGol/yadro-test-problem/domain/tariff"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestHourCost(t *testing.T) {
+	plan := tariff.Plan{
+		Classes: []tariff.Class{{Name: "vip", Tables: []int{1, 2}, HourCost: 20}},
+		Tariffs: []tariff.Tariff{
+			{From: store.NewDayTime(18, 0), HourCost: 15},
+			{From: store.NewDayTime(12, 0), Class: "vip", HourCost: 25},
+			{From: store.NewDayTime(18, 0), Class: "vip", HourCost: 30},
+		},
+	}
+
+	cases := []struct {
+		name  string
+		table int
+		since store.DayTime
+		want  float64
+	}{
+		{"table out of classes", 3, store.NewDayTime(9, 0), 10},
+		{"tariff of the tables out of classes", 3, store.NewDayTime(18, 0), 15},
+		{"class", 1, store.NewDayTime(9, 0), 20},
+		{"tariff of the class", 2, store.NewDayTime(13, 0), 25},
+		{"latest tariff of the class", 2, store.NewDayTime(20, 0), 30},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			test.AssertEqual(t, plan.HourCost(10, c.table, c.since), c.want)
+		})
+	}
+
+	t.Run("zero plan", func(t *testing.T) {
+		test.AssertTrue(t, tariff.Plan{}.IsZero())
+		test.AssertEqual(t, tariff.Plan{}.HourCost(10, 1, store.NewDayTime(20, 0)), 10.0)
+	})
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LineDiff returns a line-by-line diff of want and got, with removed lines
+// prefixed by "-", added lines by "+" and common lines by " ". It returns an
+// empty string when the texts are equal.
+func LineDiff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	buf := &strings.Builder{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(buf, "  %s\n", a[i])
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(buf, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(buf, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return buf.String()
+}
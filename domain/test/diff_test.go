@@ -0,0 +1,23 @@
+package test_test
+
+import (
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestLineDiff(t *testing.T) {
+	t.Run("equal texts", func(t *testing.T) {
+		test.AssertEqual(t, test.LineDiff("a\nb\n", "a\nb\n"), "")
+	})
+
+	t.Run("changed line", func(t *testing.T) {
+		got := test.LineDiff("a\nb\nc", "a\nx\nc")
+		test.AssertEqual(t, got, "  a\n- b\n+ x\n  c\n")
+	})
+
+	t.Run("added and removed lines", func(t *testing.T) {
+		got := test.LineDiff("a\nb", "b\nc")
+		test.AssertEqual(t, got, "- a\n  b\n+ c\n")
+	})
+}
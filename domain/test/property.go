@@ -0,0 +1,25 @@
+package test
+
+// Shrink looks for a smaller input on which fails still returns true by
+// dropping chunks of elements, halving the chunk size down to one element.
+// Dropping any single element of the result makes the failure go away.
+func Shrink[T any](input []T, fails func([]T) bool) []T {
+	for chunk := len(input) / 2; chunk >= 1; {
+		shrunk := false
+		for start := 0; start+chunk <= len(input); {
+			candidate := make([]T, 0, len(input)-chunk)
+			candidate = append(candidate, input[:start]...)
+			candidate = append(candidate, input[start+chunk:]...)
+			if fails(candidate) {
+				input = candidate
+				shrunk = true
+				continue
+			}
+			start += chunk
+		}
+		if !shrunk {
+			chunk /= 2
+		}
+	}
+	return input
+}
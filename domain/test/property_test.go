@@ -0,0 +1,38 @@
+package test_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+)
+
+func TestShrink(t *testing.T) {
+	t.Run("keeps only the elements the failure needs", func(t *testing.T) {
+		input := []int{5, 1, 7, 3, 9, 2, 8, 4, 6}
+		fails := func(xs []int) bool {
+			return slices.Contains(xs, 3) && slices.Contains(xs, 8)
+		}
+		got := test.Shrink(input, fails)
+		test.AssertEqual(t, len(got), 2)
+		test.AssertEqual(t, got[0], 3)
+		test.AssertEqual(t, got[1], 8)
+	})
+
+	t.Run("keeps the order", func(t *testing.T) {
+		input := []int{1, 2, 3, 4, 5, 6, 7, 8}
+		fails := func(xs []int) bool {
+			i, j := slices.Index(xs, 6), slices.Index(xs, 2)
+			return i >= 0 && j >= 0 && j < i
+		}
+		got := test.Shrink(input, fails)
+		test.AssertEqual(t, len(got), 2)
+		test.AssertEqual(t, got[0], 2)
+		test.AssertEqual(t, got[1], 6)
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		got := test.Shrink([]int{}, func(xs []int) bool { return true })
+		test.AssertEqual(t, len(got), 0)
+	})
+}
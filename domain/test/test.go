@@ -19,6 +19,13 @@ func AssertEqual[T comparable](t testing.TB, got, want T) {
 	}
 }
 
+func AssertDayTime(t testing.TB, got, want store.DayTime) {
+	t.Helper()
+	if !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got.Time, want.Time)
+	}
+}
+
 func AssertNoError(t testing.TB, err error) {
 	t.Helper()
 	if err != nil {
@@ -0,0 +1,183 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+var DeliveryNotFound = fmt.Errorf("delivery not found")
+
+// Delivery is a notification on its way to one URL.
+type Delivery struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	URL     string          `json:"url"`
+	Payload json.RawMessage `json:"payload"`
+	// Attempts is the number of failed attempts so far, NextAttempt is
+	// when to try again.
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+// Outbox keeps the deliveries until they are made, in the order they were
+// added.
+type Outbox interface {
+	Add(d Delivery) error
+	// Update replaces the delivery with the same ID.
+	Update(d Delivery) error
+	Remove(id string) error
+	Pending() ([]Delivery, error)
+}
+
+type MemoryOutbox struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (o *MemoryOutbox) Add(d Delivery) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deliveries = append(o.deliveries, d)
+	return nil
+}
+
+func (o *MemoryOutbox) Update(d Delivery) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	i := slices.IndexFunc(o.deliveries, func(p Delivery) bool { return p.ID == d.ID })
+	if i < 0 {
+		return DeliveryNotFound
+	}
+	o.deliveries[i] = d
+	return nil
+}
+
+func (o *MemoryOutbox) Remove(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.deliveries = slices.DeleteFunc(o.deliveries, func(d Delivery) bool { return d.ID == id })
+	return nil
+}
+
+func (o *MemoryOutbox) Pending() ([]Delivery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.deliveries), nil
+}
+
+// FileOutbox keeps the deliveries in a JSON file, so that they survive a
+// restart. The file is replaced as a whole on every change, which is fine
+// for the few notifications a club sends.
+type FileOutbox struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileOutbox returns the outbox kept at path. The file is created with
+// the first delivery.
+func NewFileOutbox(path string) *FileOutbox {
+	return &FileOutbox{path: path}
+}
+
+func (o *FileOutbox) Add(d Delivery) error {
+	return o.change(func(deliveries []Delivery) ([]Delivery, error) {
+		return append(deliveries, d), nil
+	})
+}
+
+func (o *FileOutbox) Update(d Delivery) error {
+	return o.change(func(deliveries []Delivery) ([]Delivery, error) {
+		i := slices.IndexFunc(deliveries, func(p Delivery) bool { return p.ID == d.ID })
+		if i < 0 {
+			return nil, DeliveryNotFound
+		}
+		deliveries[i] = d
+		return deliveries, nil
+	})
+}
+
+func (o *FileOutbox) Remove(id string) error {
+	return o.change(func(deliveries []Delivery) ([]Delivery, error) {
+		return slices.DeleteFunc(deliveries, func(d Delivery) bool { return d.ID == id }), nil
+	})
+}
+
+func (o *FileOutbox) Pending() ([]Delivery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	deliveries, err := o.load()
+	if err != nil {
+		return nil, fmt.Errorf("FileOutbox.Pending: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (o *FileOutbox) change(f func([]Delivery) ([]Delivery, error)) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	deliveries, err := o.load()
+	if err == nil {
+		deliveries, err = f(deliveries)
+	}
+	if err == nil {
+		err = o.save(deliveries)
+	}
+	if err != nil {
+		return fmt.Errorf("FileOutbox: %w", err)
+	}
+	return nil
+}
+
+func (o *FileOutbox) load() ([]Delivery, error) {
+	data, err := os.ReadFile(o.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var deliveries []Delivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// save writes a new file and renames it over the old one, so that a crash
+// can't leave a half-written outbox.
+func (o *FileOutbox) save(deliveries []Delivery) error {
+	data, err := json.Marshal(deliveries)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), o.path)
+}
@@ -0,0 +1,318 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GerogeGol/yadro-test-problem/domain/logging"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+)
+
+// Notification types, the keys of Sink.URLs.
+const (
+	// ClientPromoted is sent when a client leaving a table makes room for
+	// the first one in the queue.
+	ClientPromoted = "client.promoted"
+	// ClubClosed is sent at the close time with the clients sent away.
+	ClubClosed = "club.closed"
+)
+
+// Types lists the notification types.
+var Types = []string{ClientPromoted, ClubClosed}
+
+var UnknownType = fmt.Errorf("unknown notification type")
+
+// Notification is the JSON body of a webhook request.
+type Notification struct {
+	// ID is the same for the requests of a notification to different URLs
+	// and for the retries of a request, so that the receiver can drop
+	// duplicates.
+	ID      string   `json:"id"`
+	Type    string   `json:"type"`
+	Time    string   `json:"time"`
+	Client  string   `json:"client,omitempty"`
+	Table   int      `json:"table,omitempty"`
+	Clients []string `json:"clients,omitempty"`
+}
+
+// SignatureHeader holds "sha256=" and the hex HMAC-SHA256 keyed with the
+// secret of the sink of the TimestampHeader value, a dot and the body.
+const SignatureHeader = "X-Club-Signature"
+
+// TimestampHeader holds the Unix time in seconds the request was signed at,
+// so that a receiver can refuse the requests replayed later.
+const TimestampHeader = "X-Club-Timestamp"
+
+// Tolerance is how far a signature timestamp may be from the time of the
+// receiver for Verify to accept it.
+const Tolerance = 5 * time.Minute
+
+var NoSecret = fmt.Errorf("webhook secret is not set")
+
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the one of timestamp and body, in
+// constant time, and timestamp is within Tolerance of now.
+func Verify(secret, body []byte, timestamp, signature string, now time.Time) bool {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > Tolerance || d < -Tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Sink turns the domain events of the club into webhook notifications. The
+// club only waits for a notification to be put into the outbox, Run makes
+// the requests and retries the failed ones with an exponential backoff.
+type Sink struct {
+	// URLs maps a notification type to the URLs to post it to.
+	URLs   map[string][]string
+	Secret []byte
+	Client *http.Client
+	Outbox Outbox
+	// MaxAttempts is the number of requests made for a delivery before it
+	// is given up.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, every next one is twice
+	// as long, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Logger     *slog.Logger
+	wake       chan struct{}
+}
+
+// NewSink returns NoSecret for an empty secret, the receivers must be able
+// to tell the notifications of the club from forged ones.
+func NewSink(urls map[string][]string, secret []byte, outbox Outbox) (*Sink, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("NewSink: %w", NoSecret)
+	}
+	return &Sink{
+		URLs:        urls,
+		Secret:      secret,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		Outbox:      outbox,
+		MaxAttempts: 8,
+		Backoff:     time.Second,
+		MaxBackoff:  5 * time.Minute,
+		Logger:      logging.Discard(),
+		wake:        make(chan struct{}, 1),
+	}, nil
+}
+
+// Handle puts the notification about e into the outbox, it is meant to be
+// attached to the domain event bus of the club.
+func (s *Sink) Handle(e service.DomainEvent) {
+	var n Notification
+	switch e := e.(type) {
+	case service.ClientSeated:
+		if !e.FromQueue {
+			return
+		}
+		n = Notification{Type: ClientPromoted, Time: e.Time.String(), Client: e.Client, Table: e.Table}
+	case service.ClubClosed:
+		n = Notification{Type: ClubClosed, Time: e.Time.String(), Clients: e.SentAway}
+	default:
+		return
+	}
+	if err := s.Notify(n); err != nil {
+		s.Logger.Error("notification lost", "type", n.Type, "error", err)
+	}
+}
+
+// Notify puts n into the outbox, a delivery per URL of its type.
+func (s *Sink) Notify(n Notification) error {
+	urls := s.URLs[n.Type]
+	if len(urls) == 0 {
+		return nil
+	}
+	if n.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return fmt.Errorf("Sink.Notify: %w", err)
+		}
+		n.ID = id
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("Sink.Notify: %w", err)
+	}
+	for i, url := range urls {
+		d := Delivery{ID: fmt.Sprintf("%s-%d", n.ID, i), Type: n.Type, URL: url, Payload: payload}
+		if err := s.Outbox.Add(d); err != nil {
+			return fmt.Errorf("Sink.Notify: %w", err)
+		}
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run delivers the notifications from the outbox as they come until ctx is
+// done. The ones left in the outbox of an earlier run go first.
+func (s *Sink) Run(ctx context.Context) error {
+	for {
+		next, err := s.deliverDue(ctx)
+		if err != nil {
+			return err
+		}
+		var timer <-chan time.Time
+		if !next.IsZero() {
+			timer = time.After(time.Until(next))
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.wake:
+		case <-timer:
+		}
+	}
+}
+
+// Drain delivers the notifications in the outbox and returns once it is
+// empty or ctx is done. What isn't delivered stays in the outbox.
+func (s *Sink) Drain(ctx context.Context) error {
+	for {
+		next, err := s.deliverDue(ctx)
+		if err != nil || next.IsZero() {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Sink.Drain: %w", ctx.Err())
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// deliverDue makes the deliveries whose time has come and returns when the
+// next one is due, zero if the outbox is empty.
+func (s *Sink) deliverDue(ctx context.Context) (next time.Time, err error) {
+	pending, err := s.Outbox.Pending()
+	if err != nil {
+		return next, fmt.Errorf("Sink: %w", err)
+	}
+	for _, d := range pending {
+		if ctx.Err() != nil {
+			return next, nil
+		}
+		if time.Now().Before(d.NextAttempt) {
+			if next.IsZero() || d.NextAttempt.Before(next) {
+				next = d.NextAttempt
+			}
+			continue
+		}
+
+		retry, err := s.deliver(ctx, d)
+		if err == nil {
+			s.Logger.Debug("notification delivered", "id", d.ID, "type", d.Type, "url", d.URL)
+			if err := s.Outbox.Remove(d.ID); err != nil {
+				return next, fmt.Errorf("Sink: %w", err)
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			// the attempt was cut short, it doesn't count
+			return next, nil
+		}
+
+		d.Attempts++
+		if !retry || d.Attempts >= s.MaxAttempts {
+			s.Logger.Error("notification given up", "id", d.ID, "type", d.Type, "url", d.URL, "attempts", d.Attempts, "error", err)
+			if err := s.Outbox.Remove(d.ID); err != nil {
+				return next, fmt.Errorf("Sink: %w", err)
+			}
+			continue
+		}
+		d.NextAttempt = time.Now().Add(s.backoff(d.Attempts))
+		s.Logger.Warn("notification failed", "id", d.ID, "type", d.Type, "url", d.URL, "attempts", d.Attempts, "retry_at", d.NextAttempt, "error", err)
+		if err := s.Outbox.Update(d); err != nil {
+			return next, fmt.Errorf("Sink: %w", err)
+		}
+		if next.IsZero() || d.NextAttempt.Before(next) {
+			next = d.NextAttempt
+		}
+	}
+	return next, nil
+}
+
+func (s *Sink) backoff(attempts int) time.Duration {
+	d := s.Backoff
+	for i := 1; i < attempts && d < s.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, s.MaxBackoff)
+}
+
+// deliver posts d and reports whether a failed request is worth retrying:
+// network errors and server errors are, rejected requests are not.
+func (s *Sink) deliver(ctx context.Context, d Delivery) (retry bool, err error) {
+	if len(s.Secret) == 0 {
+		return false, NoSecret
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Club-Event", d.Type)
+	req.Header.Set("X-Club-Delivery", d.ID)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, Sign(s.Secret, timestamp, d.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("%s answered %s", d.URL, resp.Status)
+	}
+	return false, fmt.Errorf("%s answered %s", d.URL, resp.Status)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("newID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ParseType checks that s names a notification type.
+func ParseType(s string) (string, error) {
+	for _, t := range Types {
+		if s == t {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("webhook.ParseType: %q: %w", s, UnknownType)
+}
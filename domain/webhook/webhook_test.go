@@ -0,0 +1,292 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	memqueue "github.com/GerogeGol/yadro-test-problem/domain/queue/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/service"
+	"github.com/GerogeGol/yadro-test-problem/domain/store"
+	memstore "github.com/GerogeGol/yadro-test-problem/domain/store/memory"
+	"github.com/GerogeGol/yadro-test-problem/domain/test"
+	"github.com/GerogeGol/yadro-test-problem/domain/webhook"
+)
+
+var secret = []byte("secret")
+
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+// gateway is a messaging gateway answering with the statuses in turn, the
+// last one for the rest of the requests.
+type gateway struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []request
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.requests = append(g.requests, request{header: r.Header, body: body})
+	status := http.StatusOK
+	if len(g.statuses) > 0 {
+		status = g.statuses[0]
+		if len(g.statuses) > 1 {
+			g.statuses = g.statuses[1:]
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func (g *gateway) received() []request {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]request(nil), g.requests...)
+}
+
+func newGateway(t *testing.T, statuses ...int) (*gateway, string) {
+	g := &gateway{statuses: statuses}
+	server := httptest.NewServer(g)
+	t.Cleanup(server.Close)
+	return g, server.URL
+}
+
+func newSink(t *testing.T, url string, outbox webhook.Outbox) *webhook.Sink {
+	t.Helper()
+	s, err := webhook.NewSink(map[string][]string{webhook.ClientPromoted: {url}, webhook.ClubClosed: {url}}, secret, outbox)
+	test.AssertNoError(t, err)
+	s.Backoff, s.MaxBackoff = time.Millisecond, 4*time.Millisecond
+	return s
+}
+
+func drain(t *testing.T, s *webhook.Sink) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	test.AssertNoError(t, s.Drain(ctx))
+}
+
+func TestSink(t *testing.T) {
+	t.Run("promotions and closures are posted signed", func(t *testing.T) {
+		g, url := newGateway(t)
+		sink := newSink(t, url, webhook.NewMemoryOutbox())
+
+		club := service.NewComputerClub(1, 10, store.NewDayTime(9, 0), store.NewDayTime(19, 0), memstore.NewStore(), memqueue.NewQueue())
+		club.DomainEvents().Handle(sink.Handle)
+		test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 0), "a"))
+		test.AssertNoError(t, club.SitDown(store.NewDayTime(9, 0), "a", 1))
+		test.AssertNoError(t, club.Arrive(store.NewDayTime(9, 10), "b"))
+		_, err := club.Wait(store.NewDayTime(9, 10), "b")
+		test.AssertNoError(t, err)
+		_, _, err = club.Leave(store.NewDayTime(10, 0), "a")
+		test.AssertNoError(t, err)
+		_, err = club.Close()
+		test.AssertNoError(t, err)
+		drain(t, sink)
+
+		requests := g.received()
+		test.AssertEqual(t, len(requests), 2)
+		var notifications []webhook.Notification
+		for _, r := range requests {
+			test.AssertTrue(t, webhook.Verify(secret, r.body, r.header.Get(webhook.TimestampHeader), r.header.Get(webhook.SignatureHeader), time.Now()))
+			test.AssertEqual(t, r.header.Get("Content-Type"), "application/json")
+			var n webhook.Notification
+			test.AssertNoError(t, json.Unmarshal(r.body, &n))
+			test.AssertEqual(t, r.header.Get("X-Club-Event"), n.Type)
+			notifications = append(notifications, n)
+		}
+
+		promoted, closed := notifications[0], notifications[1]
+		test.AssertEqual(t, promoted.Type, webhook.ClientPromoted)
+		test.AssertEqual(t, promoted.Time, "10:00")
+		test.AssertEqual(t, promoted.Client, "b")
+		test.AssertEqual(t, promoted.Table, 1)
+		test.AssertEqual(t, closed.Type, webhook.ClubClosed)
+		test.AssertEqual(t, closed.Time, "19:00")
+		test.AssertEqual(t, len(closed.Clients), 1)
+		test.AssertEqual(t, closed.Clients[0], "b")
+		test.AssertTrue(t, promoted.ID != closed.ID)
+	})
+
+	t.Run("other events and types without URLs are not sent", func(t *testing.T) {
+		g, url := newGateway(t)
+		sink, err := webhook.NewSink(map[string][]string{webhook.ClubClosed: {url}}, secret, webhook.NewMemoryOutbox())
+		test.AssertNoError(t, err)
+
+		sink.Handle(service.ClientArrived{Time: store.NewDayTime(9, 0), Client: "a"})
+		sink.Handle(service.ClientSeated{Time: store.NewDayTime(9, 0), Client: "a", Table: 1})
+		sink.Handle(service.ClientSeated{Time: store.NewDayTime(9, 0), Client: "a", Table: 1, FromQueue: true})
+		drain(t, sink)
+		test.AssertEqual(t, len(g.received()), 0)
+	})
+
+	t.Run("refuses to send unsigned", func(t *testing.T) {
+		_, err := webhook.NewSink(map[string][]string{webhook.ClubClosed: {"http://gateway.example"}}, nil, webhook.NewMemoryOutbox())
+		test.AssertError(t, err, webhook.NoSecret)
+	})
+
+	t.Run("server errors are retried", func(t *testing.T) {
+		g, url := newGateway(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+		sink := newSink(t, url, webhook.NewMemoryOutbox())
+
+		test.AssertNoError(t, sink.Notify(webhook.Notification{Type: webhook.ClubClosed, Time: "19:00"}))
+		drain(t, sink)
+
+		requests := g.received()
+		test.AssertEqual(t, len(requests), 3)
+		test.AssertEqual(t, string(requests[2].body), string(requests[0].body))
+		test.AssertEqual(t, requests[2].header.Get("X-Club-Delivery"), requests[0].header.Get("X-Club-Delivery"))
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		g, url := newGateway(t, http.StatusInternalServerError)
+		sink := newSink(t, url, webhook.NewMemoryOutbox())
+		sink.MaxAttempts = 3
+
+		test.AssertNoError(t, sink.Notify(webhook.Notification{Type: webhook.ClubClosed, Time: "19:00"}))
+		drain(t, sink)
+		test.AssertEqual(t, len(g.received()), 3)
+
+		g, url = newGateway(t, http.StatusBadRequest)
+		sink = newSink(t, url, webhook.NewMemoryOutbox())
+		test.AssertNoError(t, sink.Notify(webhook.Notification{Type: webhook.ClubClosed, Time: "19:00"}))
+		drain(t, sink)
+		test.AssertEqual(t, len(g.received()), 1)
+	})
+
+	t.Run("backoff doubles", func(t *testing.T) {
+		_, url := newGateway(t, http.StatusServiceUnavailable)
+		outbox := webhook.NewMemoryOutbox()
+		sink := newSink(t, url, outbox)
+		sink.Backoff, sink.MaxBackoff = time.Hour, 3*time.Hour
+
+		test.AssertNoError(t, sink.Notify(webhook.Notification{Type: webhook.ClubClosed, Time: "19:00"}))
+		delays := []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour}
+		for i, delay := range delays {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			start := time.Now()
+			test.AssertError(t, sink.Drain(ctx), context.DeadlineExceeded)
+			cancel()
+
+			pending, err := outbox.Pending()
+			test.AssertNoError(t, err)
+			test.AssertEqual(t, len(pending), 1)
+			test.AssertEqual(t, pending[0].Attempts, i+1)
+			wait := pending[0].NextAttempt.Sub(start)
+			test.AssertTrue(t, wait >= delay && wait < delay+time.Second)
+
+			// make it due again
+			pending[0].NextAttempt = time.Time{}
+			test.AssertNoError(t, outbox.Update(pending[0]))
+		}
+	})
+
+	t.Run("run delivers as notifications come", func(t *testing.T) {
+		g, url := newGateway(t)
+		sink := newSink(t, url, webhook.NewMemoryOutbox())
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- sink.Run(ctx) }()
+
+		test.AssertNoError(t, sink.Notify(webhook.Notification{Type: webhook.ClubClosed, Time: "19:00"}))
+		for deadline := time.Now().Add(5 * time.Second); len(g.received()) == 0 && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		test.AssertNoError(t, <-done)
+		test.AssertEqual(t, len(g.received()), 1)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"type":"club.closed"}`)
+	now := time.Unix(1700000000, 0)
+	timestamp := "1700000000"
+	signature := webhook.Sign(secret, timestamp, body)
+
+	test.AssertTrue(t, webhook.Verify(secret, body, timestamp, signature, now))
+	test.AssertTrue(t, webhook.Verify(secret, body, timestamp, signature, now.Add(webhook.Tolerance)))
+	t.Run("replayed later", func(t *testing.T) {
+		test.AssertFalse(t, webhook.Verify(secret, body, timestamp, signature, now.Add(webhook.Tolerance+time.Second)))
+	})
+	t.Run("timestamp changed", func(t *testing.T) {
+		test.AssertFalse(t, webhook.Verify(secret, body, "1700000100", signature, now))
+		test.AssertFalse(t, webhook.Verify(secret, body, "", signature, now))
+	})
+	t.Run("body changed", func(t *testing.T) {
+		test.AssertFalse(t, webhook.Verify(secret, []byte(`{"type":"client.promoted"}`), timestamp, signature, now))
+	})
+	t.Run("other secret", func(t *testing.T) {
+		test.AssertFalse(t, webhook.Verify([]byte("other"), body, timestamp, signature, now))
+	})
+}
+
+func TestFileOutbox(t *testing.T) {
+	t.Run("notifications survive a restart", func(t *testing.T) {
+		path := t.TempDir() + "/outbox.json"
+		g, url := newGateway(t)
+
+		// the gateway can't be reached, the notification stays in the outbox
+		sink := newSink(t, url, webhook.NewFileOutbox(path))
+		sink.Backoff = time.Hour
+		sink.MaxBackoff = time.Hour
+		sink.Client.Transport = roundTripper(func(*http.Request) (*http.Response, error) { return nil, io.ErrUnexpectedEOF })
+		test.AssertNoError(t, sink.Notify(webhook.Notification{Type: webhook.ClientPromoted, Time: "10:00", Client: "b", Table: 1}))
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		test.AssertError(t, sink.Drain(ctx), context.DeadlineExceeded)
+		cancel()
+
+		outbox := webhook.NewFileOutbox(path)
+		pending, err := outbox.Pending()
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(pending), 1)
+		test.AssertEqual(t, pending[0].Attempts, 1)
+
+		// after the restart the gateway is back, the retry is made when due
+		pending[0].NextAttempt = time.Now()
+		test.AssertNoError(t, outbox.Update(pending[0]))
+		drain(t, newSink(t, url, outbox))
+
+		requests := g.received()
+		test.AssertEqual(t, len(requests), 1)
+		var n webhook.Notification
+		test.AssertNoError(t, json.Unmarshal(requests[0].body, &n))
+		test.AssertEqual(t, n.Client, "b")
+		// the payload is sent as it was signed, not as the outbox keeps it
+		test.AssertFalse(t, strings.Contains(string(requests[0].body), "\n"))
+		pending, err = outbox.Pending()
+		test.AssertNoError(t, err)
+		test.AssertEqual(t, len(pending), 0)
+	})
+
+	t.Run("unknown delivery", func(t *testing.T) {
+		outbox := webhook.NewFileOutbox(t.TempDir() + "/outbox.json")
+		test.AssertError(t, outbox.Update(webhook.Delivery{ID: "x"}), webhook.DeliveryNotFound)
+		test.AssertNoError(t, outbox.Remove("x"))
+	})
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestParseType(t *testing.T) {
+	typ, err := webhook.ParseType("club.closed")
+	test.AssertNoError(t, err)
+	test.AssertEqual(t, typ, webhook.ClubClosed)
+	_, err = webhook.ParseType("club.opened")
+	test.AssertError(t, err, webhook.UnknownType)
+}
@@ -8,6 +8,6 @@ mkdir $out_dir
 for i in $files;
 do
 	filename=`basename $i .txt`
-	go run $script_dir/../cmd/main.go $i > "$out_dir/$filename.out"
+	go run $script_dir/../cmd $i > "$out_dir/$filename.out"
 done;
 